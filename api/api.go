@@ -3,11 +3,17 @@ package api
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/deta/deta-cli/runtime"
 )
 
+var (
+	// ErrEntityTooLarge request body is too large for the server
+	ErrEntityTooLarge = errors.New("request entity too large")
+)
+
 // injects X-Resource-Addr header from account and region
 func (c *DetaClient) injectResourceHeader(headers map[string]string, account, region string) {
 	resAddr := fmt.Sprintf("aws:%s:%s", account, region)
@@ -46,6 +52,9 @@ func (c *DetaClient) Deploy(r *DeployRequest) (*DeployResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	if o.Status == 413 {
+		return nil, ErrEntityTooLarge
+	}
 	if o.Status != 200 {
 		msg := o.Error.Message
 		if msg == "" {
@@ -159,6 +168,40 @@ func (c *DetaClient) DownloadProgram(req *DownloadProgramRequest) (*DownloadProg
 	}, nil
 }
 
+// DeleteProgramRequest request to delete a program
+type DeleteProgramRequest struct {
+	ProgramID string
+	Account   string
+	Region    string
+}
+
+// DeleteProgram delete a program
+func (c *DetaClient) DeleteProgram(req *DeleteProgramRequest) error {
+	headers := make(map[string]string)
+	c.injectResourceHeader(headers, req.Account, req.Region)
+
+	i := &requestInput{
+		Path:      fmt.Sprintf("/programs/%s", req.ProgramID),
+		Method:    "DELETE",
+		Headers:   headers,
+		NeedsAuth: true,
+	}
+
+	o, err := c.request(i)
+	if err != nil {
+		return err
+	}
+
+	if o.Status != 200 {
+		msg := o.Error.Message
+		if msg == "" {
+			msg = o.Error.Errors[0]
+		}
+		return fmt.Errorf("failed to delete micro: %v", msg)
+	}
+	return nil
+}
+
 // ListSpaceItem an item in ListSpacesResponse
 type ListSpaceItem struct {
 	SpaceID int64  `json:"spaceID"`
@@ -522,6 +565,139 @@ func (c *DetaClient) GetProjects(req *GetProjectsRequest) (*GetProjectsResponse,
 	return &resp, nil
 }
 
+// CreateProjectRequest request to create a project
+type CreateProjectRequest struct {
+	SpaceID     int64  `json:"-"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// CreateProject creates a project
+func (c *DetaClient) CreateProject(req *CreateProjectRequest) (*GetProjectsItem, error) {
+	i := &requestInput{
+		Path:      fmt.Sprintf("/spaces/%d/projects", req.SpaceID),
+		Method:    "POST",
+		Body:      req,
+		NeedsAuth: true,
+	}
+	o, err := c.request(i)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Status != 200 && o.Status != 201 {
+		msg := o.Error.Message
+		if msg == "" {
+			msg = o.Error.Errors[0]
+		}
+		return nil, fmt.Errorf("failed to create project: %v", msg)
+	}
+
+	var resp GetProjectsItem
+	err = json.Unmarshal(o.Body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create project: %v", err)
+	}
+	return &resp, nil
+}
+
+// UpdateProjectNameRequest request to update the name of a project
+type UpdateProjectNameRequest struct {
+	SpaceID   int64  `json:"-"`
+	ProjectID string `json:"-"`
+	Name      string `json:"name"`
+}
+
+// UpdateProjectName updates the name of a project
+func (c *DetaClient) UpdateProjectName(req *UpdateProjectNameRequest) error {
+	i := &requestInput{
+		Path:      fmt.Sprintf("/spaces/%d/projects/%s", req.SpaceID, req.ProjectID),
+		Method:    "PATCH",
+		Body:      req,
+		NeedsAuth: true,
+	}
+	o, err := c.request(i)
+	if err != nil {
+		return err
+	}
+
+	if o.Status != 200 {
+		msg := o.Error.Message
+		if msg == "" {
+			msg = o.Error.Errors[0]
+		}
+		return fmt.Errorf("failed to update project name: %v", msg)
+	}
+	return nil
+}
+
+// DeleteProjectRequest request to delete a project
+type DeleteProjectRequest struct {
+	SpaceID   int64
+	ProjectID string
+}
+
+// DeleteProject deletes a project
+func (c *DetaClient) DeleteProject(req *DeleteProjectRequest) error {
+	i := &requestInput{
+		Path:      fmt.Sprintf("/spaces/%d/projects/%s", req.SpaceID, req.ProjectID),
+		Method:    "DELETE",
+		NeedsAuth: true,
+	}
+	o, err := c.request(i)
+	if err != nil {
+		return err
+	}
+
+	if o.Status != 200 {
+		msg := o.Error.Message
+		if msg == "" {
+			msg = o.Error.Errors[0]
+		}
+		return fmt.Errorf("failed to delete project: %v", msg)
+	}
+	return nil
+}
+
+// GetProgramsRequest request to get the programs of a project
+type GetProgramsRequest struct {
+	Project string
+	Space   int64
+}
+
+// GetProgramsResponse response to get programs request
+type GetProgramsResponse struct {
+	Programs []*GetProgDetailsResponse `json:"programs"`
+}
+
+// GetPrograms gets programs of a project
+func (c *DetaClient) GetPrograms(req *GetProgramsRequest) (*GetProgramsResponse, error) {
+	i := &requestInput{
+		Path:      fmt.Sprintf("/spaces/%d/projects/%s/programs", req.Space, req.Project),
+		Method:    "GET",
+		NeedsAuth: true,
+	}
+	o, err := c.request(i)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Status != 200 {
+		msg := o.Error.Message
+		if msg == "" {
+			msg = o.Error.Errors[0]
+		}
+		return nil, fmt.Errorf("failed to get micros: %v", msg)
+	}
+
+	var resp GetProgramsResponse
+	err = json.Unmarshal(o.Body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // GetProgDetailsRequest request to get program details
 type GetProgDetailsRequest struct {
 	Program string
@@ -620,6 +796,9 @@ type AddScheduleRequest struct {
 	ProgramID  string `json:"program_id"`
 	Type       string `json:"type"`
 	Expression string `json:"expression"`
+	Name       string `json:"name,omitempty"`   // name of the schedule, empty for the default schedule
+	Action     string `json:"action,omitempty"` // action the program is invoked with
+	Body       string `json:"body,omitempty"`   // body the program is invoked with
 }
 
 // AddSchedule add a schedule/cron to a program
@@ -649,6 +828,7 @@ func (c *DetaClient) AddSchedule(req *AddScheduleRequest) error {
 // DeleteScheduleRequest request to delete a schedule/cron from a program
 type DeleteScheduleRequest struct {
 	ProgramID string
+	Name      string // name of the schedule, empty for the default schedule
 }
 
 // DeleteSchedule delete a schedule from a program
@@ -658,6 +838,11 @@ func (c *DetaClient) DeleteSchedule(req *DeleteScheduleRequest) error {
 		Method:    "DELETE",
 		NeedsAuth: true,
 	}
+	if req.Name != "" {
+		i.QueryParams = map[string]string{
+			"name": req.Name,
+		}
+	}
 
 	o, err := c.request(i)
 	if err != nil {
@@ -684,6 +869,9 @@ type GetScheduleResponse struct {
 	ScheduleID int64  `json:"id"`
 	Type       string `json:"type"`
 	Expression string `json:"expression"`
+	Name       string `json:"name"`
+	Action     string `json:"action"`
+	Body       string `json:"body"`
 }
 
 // GetSchedule  get a schedule for a program
@@ -719,6 +907,48 @@ func (c *DetaClient) GetSchedule(req *GetScheduleRequest) (*GetScheduleResponse,
 	return &resp, nil
 }
 
+// GetSchedulesRequest request to get all schedules of a program
+type GetSchedulesRequest struct {
+	ProgramID string
+}
+
+// GetSchedulesResponse response to get schedules request
+type GetSchedulesResponse struct {
+	Schedules []*GetScheduleResponse `json:"schedules"`
+}
+
+// GetSchedules get all schedules of a program, the default and the named schedules
+func (c *DetaClient) GetSchedules(req *GetSchedulesRequest) (*GetSchedulesResponse, error) {
+	i := &requestInput{
+		Path:   "/schedules/",
+		Method: "GET",
+		QueryParams: map[string]string{
+			"program_id": req.ProgramID,
+		},
+		NeedsAuth: true,
+	}
+
+	o, err := c.request(i)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Status != 200 {
+		msg := o.Error.Message
+		if msg == "" {
+			msg = o.Error.Errors[0]
+		}
+		return nil, fmt.Errorf("failed to get schedules: %v", msg)
+	}
+
+	var resp GetSchedulesResponse
+	err = json.Unmarshal(o.Body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // GetUserInfoResponse response to GetUserInfo request
 type GetUserInfoResponse struct {
 	DefaultSpace     int64
@@ -732,6 +962,9 @@ func (c *DetaClient) GetUserInfo() (*GetUserInfoResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(resp) == 0 {
+		return nil, fmt.Errorf("failed to get user info: no spaces found")
+	}
 
 	return &GetUserInfoResponse{
 		DefaultSpace:     resp[0].SpaceID,
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/deta/deta-cli/auth"
@@ -30,6 +31,9 @@ var (
 type DetaClient struct {
 	rootEndpoint string
 	client       *http.Client
+	// serializes getting the auth tokens so expired tokens are refreshed only once
+	// by concurrent requests
+	authMu sync.Mutex
 }
 
 // NewDetaClient new client to talk with the deta api
@@ -112,7 +116,9 @@ func (d *DetaClient) request(i *requestInput) (*requestOutput, error) {
 	// auth
 	if i.NeedsAuth {
 		authManager := auth.NewManager()
+		d.authMu.Lock()
 		tokens, err := authManager.GetTokens()
+		d.authMu.Unlock()
 		if err != nil {
 			if errors.Is(err, auth.ErrRefreshTokenInvalid) {
 				return nil, fmt.Errorf("auth token expired, re-login required")
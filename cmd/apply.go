@@ -0,0 +1,422 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/cron"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyDryRun    bool
+	applyEnvValues bool
+
+	applyCmd = &cobra.Command{
+		Use:     "apply [path]",
+		Short:   "Update a deta micro to match its manifest",
+		Args:    cobra.MaximumNArgs(1),
+		Example: applyExamples(),
+		RunE:    apply,
+	}
+)
+
+// applyStep an update of the micro to match the manifest
+type applyStep struct {
+	description string
+	run         func() error
+}
+
+func init() {
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "show the updates that would be made without updating")
+	applyCmd.Flags().BoolVar(&applyEnvValues, "env-values", false, "also update the values of env vars already set on the micro")
+	rootCmd.AddCommand(applyCmd)
+}
+
+func apply(cmd *cobra.Command, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		wd = args[0]
+	}
+
+	runtimeManager, err := runtime.NewManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return err
+	}
+	if !isInitialized {
+		return fmt.Errorf("no deta micro initialized in '%s'", wd)
+	}
+
+	manifest, err := runtimeManager.GetManifest()
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return fmt.Errorf("no manifest present in '%s', see `deta apply --help`", wd)
+	}
+
+	progInfo, err := runtimeManager.GetProgInfo()
+	if err != nil {
+		return err
+	}
+
+	// reconcile against the config of the micro on the server, not the cached info
+	progDetails, err := client.GetProgDetails(&api.GetProgDetailsRequest{
+		Program: progInfo.ID,
+		Project: progInfo.Project,
+		Space:   progInfo.Space,
+	})
+	if err != nil {
+		return err
+	}
+	progInfo.Name = progDetails.Name
+	progInfo.Runtime = progDetails.Runtime
+	progInfo.Envs = progDetails.Envs
+	progInfo.Public = progDetails.Public
+	progInfo.Visor = progDetails.Visor
+	if manifest.Cron != nil {
+		schedule, err := client.GetSchedule(&api.GetScheduleRequest{
+			ProgramID: progInfo.ID,
+		})
+		if err != nil {
+			return err
+		}
+		progInfo.Cron = ""
+		if schedule != nil {
+			progInfo.Cron = schedule.Expression
+		}
+	}
+	// a dry run does not change the cached info
+	if !applyDryRun {
+		err = runtimeManager.StoreProgInfo(progInfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	if manifest.Project != "" {
+		err = checkManifestProject(manifest, progInfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	steps, err := getApplySteps(runtimeManager, manifest, progInfo)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		fmt.Println("Micro already matches the manifest")
+		return nil
+	}
+
+	if applyDryRun {
+		fmt.Printf("Updates to match '%s':\n", manifest.File())
+		for _, s := range steps {
+			fmt.Printf("  ~ %s\n", s.description)
+		}
+		fmt.Println()
+		fmt.Println("Dry run, nothing was updated")
+		return nil
+	}
+
+	for _, s := range steps {
+		fmt.Printf("%s...\n", s.description)
+		err = s.run()
+		if err != nil {
+			return err
+		}
+		// store info after each step to keep it in sync if a later step fails
+		err = runtimeManager.StoreProgInfo(progInfo)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Successfully applied '%s'\n", manifest.File())
+	return nil
+}
+
+// checkManifestProject checks if the micro is in the project of the manifest
+// micros can not be moved to other projects
+func checkManifestProject(mf *runtime.Manifest, p *runtime.ProgInfo) error {
+	res, err := client.GetProjects(&api.GetProjectsRequest{
+		SpaceID: p.Space,
+	})
+	if err != nil {
+		return err
+	}
+	for _, project := range res.Projects {
+		if project.ID != p.Project {
+			continue
+		}
+		if project.Name != mf.Project && project.ID != mf.Project {
+			return fmt.Errorf("micro is in project '%s' not '%s', moving micros to other projects is not supported", project.Name, mf.Project)
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to find project of the micro")
+}
+
+// getApplySteps gets the updates of the micro needed to match the manifest
+// steps update the program info as they run
+func getApplySteps(m *runtime.Manager, mf *runtime.Manifest, p *runtime.ProgInfo) ([]*applyStep, error) {
+	var steps []*applyStep
+
+	if mf.Name != "" && mf.Name != p.Name {
+		steps = append(steps, &applyStep{
+			description: fmt.Sprintf("Updating the name to '%s'", mf.Name),
+			run: func() error {
+				err := client.UpdateProgName(&api.UpdateProgNameRequest{
+					ProgramID: p.ID,
+					Name:      mf.Name,
+				})
+				if err != nil {
+					return err
+				}
+				p.Name = mf.Name
+				return nil
+			},
+		})
+	}
+
+	if mf.Runtime != "" {
+		progRuntime, err := parseRuntime(mf.Runtime)
+		if err != nil {
+			return nil, err
+		}
+		if progRuntime.Version != p.Runtime {
+			steps = append(steps, &applyStep{
+				description: fmt.Sprintf("Updating runtime to '%s'", mf.Runtime),
+				run: func() error {
+					err := client.UpdateProgRuntime(&api.UpdateProgRuntimeRequest{
+						ProgramID: p.ID,
+						Runtime:   progRuntime.Version,
+					})
+					if err != nil {
+						return err
+					}
+					p.Runtime = progRuntime.Version
+					return nil
+				},
+			})
+		}
+	}
+
+	if mf.Env != "" {
+		envChanges, err := m.GetEnvChanges(mf.Env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file '%s': %v", mf.Env, err)
+		}
+		var fileVars map[string]string
+		if envChanges != nil {
+			fileVars = envChanges.Vars
+		}
+		if step := getEnvApplyStep(mf.Env, fileVars, p); step != nil {
+			steps = append(steps, step)
+		}
+	}
+
+	if mf.Cron != nil {
+		// expressions are compared and sent normalized like with cron set
+		var expr, cronType string
+		if strings.TrimSpace(*mf.Cron) != "" {
+			var err error
+			expr, err = cron.Normalize(*mf.Cron)
+			if err == nil {
+				cronType, err = getCronTypeFromExpr(expr)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("cron of '%s': %w, see `deta cron set --help`", mf.File(), err)
+			}
+		}
+		current := p.Cron
+		if normalized, err := cron.Normalize(current); err == nil {
+			current = normalized
+		}
+
+		switch {
+		case expr == current:
+		case expr == "":
+			steps = append(steps, &applyStep{
+				description: "Removing schedule",
+				run: func() error {
+					err := client.DeleteSchedule(&api.DeleteScheduleRequest{
+						ProgramID: p.ID,
+					})
+					if err != nil {
+						return err
+					}
+					p.Cron = ""
+					return nil
+				},
+			})
+		default:
+			steps = append(steps, &applyStep{
+				description: fmt.Sprintf("Scheduling micro for '%s'", expr),
+				run: func() error {
+					err := client.AddSchedule(&api.AddScheduleRequest{
+						ProgramID:  p.ID,
+						Type:       cronType,
+						Expression: expr,
+					})
+					if err != nil {
+						return err
+					}
+					p.Cron = expr
+					return nil
+				},
+			})
+		}
+	}
+
+	if mf.Visor != "" && mf.Visor != p.Visor {
+		steps = append(steps, &applyStep{
+			description: fmt.Sprintf("Updating visor mode to '%s'", mf.Visor),
+			run: func() error {
+				err := client.UpdateVisorMode(&api.UpdateVisorModeRequest{
+					ProgramID: p.ID,
+					Mode:      mf.Visor,
+				})
+				if err != nil {
+					return err
+				}
+				p.Visor = mf.Visor
+				return nil
+			},
+		})
+	}
+
+	if mf.Auth != nil && *mf.Auth == p.Public {
+		auth := *mf.Auth
+		description := "Disabling http auth"
+		if auth {
+			description = "Enabling http auth"
+		}
+		steps = append(steps, &applyStep{
+			description: description,
+			run: func() error {
+				err := client.UpdateAuth(&api.UpdateAuthRequest{
+					ProgramID: p.ID,
+					AuthValue: auth,
+				})
+				if err != nil {
+					return err
+				}
+				p.Public = !auth
+				return nil
+			},
+		})
+	}
+
+	return steps, nil
+}
+
+// getEnvApplyStep gets the update of the env vars of the micro to the vars of the env file
+// values of env vars are not known, so only added and removed keys are updated unless --env-values is set
+// returns nil if no update is needed
+func getEnvApplyStep(envFile string, vars map[string]string, p *runtime.ProgInfo) *applyStep {
+	updates := make(map[string]*string)
+	var added, updated, removed int
+	for k, v := range vars {
+		// cant' take the address of iterated value directly
+		value := v
+		switch {
+		case !inSlice(p.Envs, k):
+			added++
+		case applyEnvValues:
+			updated++
+		default:
+			continue
+		}
+		updates[k] = &value
+	}
+	for _, k := range p.Envs {
+		if _, ok := vars[k]; !ok {
+			updates[k] = nil
+			removed++
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	var counts []string
+	for _, c := range []struct {
+		count int
+		label string
+	}{{added, "added"}, {updated, "updated"}, {removed, "removed"}} {
+		if c.count > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", c.count, c.label))
+		}
+	}
+	return &applyStep{
+		description: fmt.Sprintf("Updating environment variables from '%s' (%s)", envFile, strings.Join(counts, ", ")),
+		run: func() error {
+			err := client.UpdateProgEnvs(&api.UpdateProgEnvsRequest{
+				ProgramID: p.ID,
+				Account:   p.Account,
+				Region:    p.Region,
+				Vars:      updates,
+			})
+			if err != nil {
+				return err
+			}
+			for k, v := range updates {
+				if v == nil {
+					p.Envs = removeFromSlice(p.Envs, k)
+				} else if !inSlice(p.Envs, k) {
+					p.Envs = append(p.Envs, k)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func applyExamples() string {
+	return `
+1. deta apply
+
+Update the deta micro in the current directory to match the manifest 'deta.json' or 'deta.yaml'.
+
+2. deta apply --dry-run
+
+Show the updates that would be made to match the manifest without updating.
+
+3. deta apply --env-values
+
+Update the deta micro to match the manifest and set the values of all env vars of the env file,
+by default only env vars missing on the micro are set and env vars not in the env file removed.
+
+Example 'deta.yaml':
+
+name: my-micro
+project: default
+runtime: python3.9
+env: .env
+cron: "5 minutes"
+visor: debug
+auth: false
+ignore:
+  - "*.log"
+  - tests/
+include:
+  - path: ../shared/utils
+    prefix: utils
+
+Fields not present in the manifest are not updated.
+An empty cron expression removes the schedule of the micro.
+Ignore rules use the syntax of .detaignore files and also apply to deployments.
+Included directories are deployed with the micro under their prefix.`
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/deta/deta-cli/runtime"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetApplySteps(t *testing.T) {
+	emptyCron := ""
+	cron := "5 minutes"
+	invalidCron := "5"
+	sameCron := " 1  Minute "
+	upperCron := "5 Minutes"
+	invalidUnitCron := "5 weeks"
+	auth := true
+
+	progInfo := runtime.ProgInfo{
+		Name:    "my-micro",
+		Runtime: "python3.9",
+		Public:  true,
+		Visor:   "off",
+		Cron:    "1 minute",
+	}
+
+	testCases := []struct {
+		manifest runtime.Manifest
+		steps    []string
+		err      bool
+	}{
+		{runtime.Manifest{}, nil, false},
+		{runtime.Manifest{Name: "my-micro", Runtime: "python3.9", Visor: "off"}, nil, false},
+		{runtime.Manifest{Name: "new-name"}, []string{"Updating the name to 'new-name'"}, false},
+		{runtime.Manifest{Runtime: "nodejs14"}, []string{"Updating runtime to 'nodejs14'"}, false},
+		{runtime.Manifest{Runtime: "python2"}, nil, true},
+		{runtime.Manifest{Cron: &cron}, []string{"Scheduling micro for '5 minutes'"}, false},
+		{runtime.Manifest{Cron: &emptyCron}, []string{"Removing schedule"}, false},
+		{runtime.Manifest{Cron: &invalidCron}, nil, true},
+		{runtime.Manifest{Cron: &sameCron}, nil, false},
+		{runtime.Manifest{Cron: &upperCron}, []string{"Scheduling micro for '5 minutes'"}, false},
+		{runtime.Manifest{Cron: &invalidUnitCron}, nil, true},
+		{runtime.Manifest{Visor: "debug", Auth: &auth}, []string{"Updating visor mode to 'debug'", "Enabling http auth"}, false},
+	}
+
+	for _, tc := range testCases {
+		msg := fmt.Sprintf("manifest: %+v", tc.manifest)
+		p := progInfo
+		steps, err := getApplySteps(nil, &tc.manifest, &p)
+		if tc.err {
+			assert.Assert(t, err != nil, msg)
+			continue
+		}
+		assert.NilError(t, err, msg)
+
+		var descriptions []string
+		for _, s := range steps {
+			descriptions = append(descriptions, s.description)
+		}
+		assert.DeepEqual(t, tc.steps, descriptions)
+	}
+}
+
+func TestGetEnvApplyStep(t *testing.T) {
+	defer func() {
+		applyEnvValues = false
+	}()
+	p := &runtime.ProgInfo{
+		Envs: []string{"KEEP", "OLD"},
+	}
+
+	testCases := []struct {
+		vars        map[string]string
+		envValues   bool
+		description string
+	}{
+		{map[string]string{"KEEP": "1", "OLD": "2"}, false, ""},
+		{map[string]string{"KEEP": "1", "OLD": "2"}, true, "Updating environment variables from '.env' (2 updated)"},
+		{map[string]string{"KEEP": "1", "NEW": "2"}, false, "Updating environment variables from '.env' (1 added, 1 removed)"},
+		{map[string]string{"KEEP": "1", "NEW": "2"}, true, "Updating environment variables from '.env' (1 added, 1 updated, 1 removed)"},
+		{nil, false, "Updating environment variables from '.env' (2 removed)"},
+	}
+
+	for _, tc := range testCases {
+		applyEnvValues = tc.envValues
+		step := getEnvApplyStep(".env", tc.vars, p)
+		if tc.description == "" {
+			assert.Assert(t, step == nil, "vars: %v", tc.vars)
+			continue
+		}
+		assert.Assert(t, step != nil, "vars: %v", tc.vars)
+		assert.Equal(t, tc.description, step.description)
+	}
+}
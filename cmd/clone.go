@@ -24,6 +24,7 @@ var (
 func init() {
 	cloneCmd.Flags().StringVar(&progName, "name", "", "deta micro name")
 	cloneCmd.Flags().StringVar(&projectName, "project", "", "project to clone the micro from")
+	cloneCmd.Flags().StringVar(&spaceFlag, "space", "", spaceUsage)
 	cloneCmd.MarkFlagRequired("name")
 
 	rootCmd.AddCommand(cloneCmd)
@@ -82,6 +83,7 @@ func clone(cmd *cobra.Command, args []string) error {
 		cleanup(wd)
 		return err
 	}
+	runtimeManager.SetCLIVersion(detaVersion)
 
 	u, err := getUserInfo(runtimeManager, client)
 	if err != nil {
@@ -102,33 +104,8 @@ func clone(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var cronExpression string
-	if progDetails.ScheduleID > 0 {
-		schedule, err := client.GetSchedule(&api.GetScheduleRequest{
-			ProgramID: progDetails.ID,
-		})
-		if err != nil {
-			cleanup(wd)
-			return err
-		}
-		cronExpression = schedule.Expression
-	}
-
-	progInfo := &runtime.ProgInfo{
-		ID:      progDetails.ID,
-		Space:   progDetails.Space,
-		Runtime: progDetails.Runtime,
-		Name:    progDetails.Name,
-		Path:    progDetails.Path,
-		Project: progDetails.Project,
-		Account: progDetails.Account,
-		Region:  progDetails.Region,
-		Deps:    progDetails.Deps,
-		Envs:    progDetails.Envs,
-		Public:  progDetails.Public,
-		Visor:   progDetails.Visor,
-		Cron:    cronExpression,
-	}
+	progInfo := progInfoFromDetails(progDetails, "")
+	setProgSchedules(progInfo)
 
 	fmt.Println("Cloning...")
 	o, err := client.DownloadProgram(&api.DownloadProgramRequest{
@@ -154,7 +131,8 @@ func clone(cmd *cobra.Command, args []string) error {
 		cleanup(wd)
 		return err
 	}
-	err = runtimeManager.StoreState()
+	// stores the state and the deployed code as the base of the next pull
+	_, err = runtimeManager.Pull(o.ZipFile, true)
 	if err != nil {
 		cleanup(wd)
 		return err
@@ -15,5 +15,6 @@ var (
 )
 
 func init() {
+	cronCmd.PersistentFlags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
 	rootCmd.AddCommand(cronCmd)
 }
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/cron"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleName   string
+	scheduleAction string
+
+	// names of named schedules
+	scheduleNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+	cronAddCmd = &cobra.Command{
+		Use:     "add [flags] [path] <expression> [-- <input args>]",
+		Short:   "Add a named schedule running an action of a deta micro",
+		Example: addExamples(),
+		RunE:    addCron,
+	}
+)
+
+func init() {
+	cronAddCmd.Flags().StringVar(&scheduleName, "name", "", "name of the schedule")
+	cronAddCmd.Flags().StringVar(&scheduleAction, "action", "", "action to run the micro with")
+	cronAddCmd.MarkFlagRequired("name")
+	cronCmd.AddCommand(cronAddCmd)
+}
+
+func addCron(cmd *cobra.Command, args []string) error {
+	if !scheduleNameRegexp.MatchString(scheduleName) {
+		return fmt.Errorf("invalid schedule name '%s', only letters, digits, '-' and '_' are allowed", scheduleName)
+	}
+
+	// args after '--' are the input of the micro
+	var inputArgs []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		inputArgs = args[dash:]
+		args = args[:dash]
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	var expr string
+	switch len(args) {
+	case 2:
+		wd = args[0]
+		expr = args[1]
+	case 1:
+		expr = args[0]
+	case 0:
+		return fmt.Errorf("no expression provided")
+	default:
+		return fmt.Errorf("too many arguments, see `deta cron add --help`")
+	}
+
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return err
+	}
+
+	if !isInitialized {
+		return notInitializedError(wd)
+	}
+
+	expr, err = cron.Normalize(expr)
+	if err != nil {
+		return fmt.Errorf("%v, see `deta cron set --help`", err)
+	}
+	cronType, err := getCronTypeFromExpr(expr)
+	if err != nil {
+		return fmt.Errorf("%v, see `deta cron set --help`", err)
+	}
+
+	progInfo, err := runtimeManager.GetProgInfo()
+	if err != nil {
+		return err
+	}
+
+	if progInfo == nil {
+		return fmt.Errorf("failed to get micro info")
+	}
+
+	var body string
+	if len(inputArgs) != 0 {
+		_, input := parseArgs(append([]string{""}, inputArgs...))
+		b, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		body = string(b)
+	}
+
+	fmt.Println("Scheduling micro...")
+	err = client.AddSchedule(&api.AddScheduleRequest{
+		ProgramID:  progInfo.ID,
+		Type:       cronType,
+		Expression: expr,
+		Name:       scheduleName,
+		Action:     scheduleAction,
+		Body:       body,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Successfully added schedule '%s' for '%s'\n", scheduleName, expr)
+
+	progInfo.SetSchedule(&runtime.Schedule{
+		Name:       scheduleName,
+		Expression: expr,
+		Action:     scheduleAction,
+		Body:       body,
+	})
+	runtimeManager.StoreProgInfo(progInfo)
+	return nil
+}
+
+func addExamples() string {
+	return `
+1. deta cron add --name nightly-cleanup --action cleanup "0 3 * * ? *"
+
+Run the action 'cleanup' of the micro in the current directory at 3:00 am(UTC) every day.
+
+2. deta cron add --name report --action report "0 9 ? * MON *" -- --channel team -verbose
+
+Run the action 'report' every monday at 9:00 am(UTC) with the following input:
+{
+	"channel": "team",
+	"verbose": true
+}
+
+3. deta cron add --name ping micros/my-micro "5 minutes"
+
+Run the micro in './micros/my-micro' every five minutes.
+
+Each micro can have several named schedules next to the schedule of 'deta cron set'.
+See the expressions in 'deta cron set --help'.`
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// name shown for the schedule set with cron set
+	defaultScheduleName = "(default)"
+)
+
+var (
+	cronListOutput string
+
+	cronListCmd = &cobra.Command{
+		Use:     "list [flags] [path]",
+		Short:   "List the schedules of a deta micro",
+		Args:    cobra.MaximumNArgs(1),
+		Example: cronListExamples(),
+		RunE:    listCron,
+	}
+)
+
+type scheduleOutput struct {
+	Name       string `json:"name,omitempty"`
+	Expression string `json:"expression"`
+	Action     string `json:"action,omitempty"`
+	Input      string `json:"input,omitempty"`
+}
+
+func init() {
+	cronListCmd.Flags().StringVarP(&cronListOutput, "output", "o", textOutput, "output format, 'text' or 'json'")
+	cronCmd.AddCommand(cronListCmd)
+}
+
+func listCron(cmd *cobra.Command, args []string) error {
+	if cronListOutput != textOutput && cronListOutput != jsonOutput {
+		return fmt.Errorf("unsupported output format '%s', see `deta cron list --help`", cronListOutput)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		wd = args[0]
+	}
+
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return err
+	}
+
+	if !isInitialized {
+		return notInitializedError(wd)
+	}
+
+	progInfo, err := runtimeManager.GetProgInfo()
+	if err != nil {
+		return err
+	}
+
+	if progInfo == nil {
+		return fmt.Errorf("failed to get micro info")
+	}
+
+	// schedules are listed from the server and refresh the cached schedules
+	progInfo.Cron, progInfo.Schedules, err = getSchedules(progInfo.ID)
+	if err != nil {
+		return err
+	}
+	err = runtimeManager.StoreProgInfo(progInfo)
+	if err != nil {
+		return err
+	}
+
+	outputs := newSchedulesOutput(progInfo)
+	if cronListOutput == jsonOutput {
+		output, err := prettyPrint(outputs)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+		return nil
+	}
+
+	if len(outputs) == 0 {
+		fmt.Printf("No schedules set for micro '%s'\n", progInfo.Name)
+		return nil
+	}
+	fmt.Print(formatSchedulesTable(outputs))
+	return nil
+}
+
+// newSchedulesOutput gets the output of the schedules of a micro, the default schedule first
+func newSchedulesOutput(p *runtime.ProgInfo) []*scheduleOutput {
+	outputs := make([]*scheduleOutput, 0, len(p.Schedules)+1)
+	if p.Cron != "" {
+		outputs = append(outputs, &scheduleOutput{
+			Expression: p.Cron,
+		})
+	}
+	for _, s := range p.Schedules {
+		outputs = append(outputs, &scheduleOutput{
+			Name:       s.Name,
+			Expression: s.Expression,
+			Action:     s.Action,
+			Input:      s.Body,
+		})
+	}
+	return outputs
+}
+
+// formatSchedulesTable formats schedules as a table with a row for each schedule
+func formatSchedulesTable(outputs []*scheduleOutput) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tEXPRESSION\tACTION\tINPUT")
+	for _, o := range outputs {
+		name, action, input := o.Name, o.Action, o.Input
+		if name == "" {
+			name = defaultScheduleName
+		}
+		if action == "" {
+			action = "-"
+		}
+		if input == "" {
+			input = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, o.Expression, action, input)
+	}
+	w.Flush()
+	return b.String()
+}
+
+func cronListExamples() string {
+	return `
+1. deta cron list
+
+List the schedules of the micro in the current directory.
+
+2. deta cron list --output json micros/my-micro
+
+List the schedules of the micro in './micros/my-micro' in json format.`
+}
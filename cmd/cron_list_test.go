@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/deta/deta-cli/runtime"
+	"gotest.tools/v3/assert"
+)
+
+func TestFormatSchedulesTable(t *testing.T) {
+	progInfo := &runtime.ProgInfo{
+		Cron: "5 minutes",
+		Schedules: []*runtime.Schedule{
+			{
+				Name:       "nightly-cleanup",
+				Expression: "0 3 * * ? *",
+				Action:     "cleanup",
+			},
+			{
+				Name:       "report",
+				Expression: "0 9 ? * MON *",
+				Action:     "report",
+				Body:       `{"channel":"team"}`,
+			},
+		},
+	}
+	expected := "" +
+		"NAME             EXPRESSION     ACTION   INPUT\n" +
+		"(default)        5 minutes      -        -\n" +
+		"nightly-cleanup  0 3 * * ? *    cleanup  -\n" +
+		"report           0 9 ? * MON *  report   {\"channel\":\"team\"}\n"
+	assert.Equal(t, expected, formatSchedulesTable(newSchedulesOutput(progInfo)))
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/deta/deta-cli/cron"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// default number of fire times of cron next and cron simulate
+	defaultCronCount = 5
+)
+
+var (
+	cronCount int
+	cronZone  string
+
+	cronNextCmd = &cobra.Command{
+		Use:     "next [flags] [expression]",
+		Short:   "Show the next times a schedule runs",
+		Args:    cobra.MaximumNArgs(1),
+		Example: nextExamples(),
+		RunE:    nextCron,
+	}
+)
+
+func init() {
+	cronNextCmd.Flags().IntVarP(&cronCount, "count", "n", defaultCronCount, "number of times to show")
+	cronNextCmd.Flags().StringVar(&scheduleName, "name", "", "name of the schedule of the micro without an expression, the default schedule if empty")
+	cronNextCmd.Flags().StringVar(&cronZone, "zone", "UTC", "time zone to show the times in, e.g. 'Europe/Berlin' or 'Local'")
+	cronCmd.AddCommand(cronNextCmd)
+}
+
+func nextCron(cmd *cobra.Command, args []string) error {
+	if cronCount < 1 {
+		return fmt.Errorf("count must be a positive number")
+	}
+	loc, err := time.LoadLocation(cronZone)
+	if err != nil {
+		return fmt.Errorf("unknown time zone '%s'", cronZone)
+	}
+
+	var expr string
+	if len(args) != 0 {
+		expr = args[0]
+	} else {
+		progInfo, err := getMicroInfo()
+		if err != nil {
+			return err
+		}
+		schedule, err := getMicroSchedule(progInfo, scheduleName)
+		if err != nil {
+			return err
+		}
+		expr = schedule.Expression
+	}
+
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("%v, see `deta cron set --help`", err)
+	}
+
+	times := cron.NextN(schedule, time.Now().UTC(), cronCount)
+	if len(times) == 0 {
+		fmt.Printf("Schedule '%s' does not run again\n", expr)
+		return nil
+	}
+	fmt.Printf("Next times of schedule '%s':\n", expr)
+	for _, t := range times {
+		fmt.Println(t.In(loc).Format(time.RFC1123))
+	}
+	return nil
+}
+
+// getMicroInfo gets the info of the micro in the current directory
+func getMicroInfo() (*runtime.ProgInfo, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return nil, err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return nil, err
+	}
+	if !isInitialized {
+		return nil, notInitializedError(wd)
+	}
+
+	progInfo, err := runtimeManager.GetProgInfo()
+	if err != nil {
+		return nil, err
+	}
+	if progInfo == nil {
+		return nil, fmt.Errorf("failed to get micro info")
+	}
+	return progInfo, nil
+}
+
+// getMicroSchedule gets the schedule of a micro with name, the default schedule if name is empty
+func getMicroSchedule(p *runtime.ProgInfo, name string) (*runtime.Schedule, error) {
+	if name != "" {
+		schedule := p.GetSchedule(name)
+		if schedule == nil {
+			return nil, fmt.Errorf("no schedule '%s' for micro '%s', see `deta cron list`", name, p.Name)
+		}
+		return schedule, nil
+	}
+	if p.Cron == "" {
+		return nil, fmt.Errorf("no schedule set for micro '%s', provide an expression or see `deta cron set --help`", p.Name)
+	}
+	return &runtime.Schedule{
+		Expression: p.Cron,
+	}, nil
+}
+
+func nextExamples() string {
+	return `
+1. deta cron next
+
+Show the next 5 times the schedule of the micro in the current directory runs in UTC.
+
+2. deta cron next "0/15 8-17 ? * MON-FRI *" --count 10 --zone Europe/Berlin
+
+Show the next 10 times the cron expression runs in the time zone 'Europe/Berlin'.
+
+3. deta cron next "2 hours"
+
+Show the next 5 times the rate expression runs from now in UTC.
+
+4. deta cron next --name nightly-cleanup
+
+Show the next 5 times the schedule 'nightly-cleanup' of the micro in the current directory runs in UTC.`
+}
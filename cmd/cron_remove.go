@@ -5,13 +5,12 @@ import (
 	"os"
 
 	"github.com/deta/deta-cli/api"
-	"github.com/deta/deta-cli/runtime"
 	"github.com/spf13/cobra"
 )
 
 var (
 	cronRemoveCmd = &cobra.Command{
-		Use:   "remove [path]",
+		Use:   "remove [flags] [path]",
 		Short: "Remove a schedule from a deta micro",
 		Args:  cobra.MaximumNArgs(1),
 		RunE:  removeCron,
@@ -19,6 +18,7 @@ var (
 )
 
 func init() {
+	cronRemoveCmd.Flags().StringVar(&scheduleName, "name", "", "name of the schedule added with deta cron add, the default schedule if empty")
 	cronCmd.AddCommand(cronRemoveCmd)
 }
 
@@ -31,7 +31,7 @@ func removeCron(cmd *cobra.Command, args []string) error {
 		wd = args[0]
 	}
 
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	runtimeManager, err := newProfileManager(&wd, false)
 	if err != nil {
 		return err
 	}
@@ -42,7 +42,7 @@ func removeCron(cmd *cobra.Command, args []string) error {
 	}
 
 	if !isInitialized {
-		return fmt.Errorf("no deta micro present in '%s'", wd)
+		return notInitializedError(wd)
 	}
 
 	progInfo, err := runtimeManager.GetProgInfo()
@@ -54,13 +54,22 @@ func removeCron(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get micro info")
 	}
 
+	// the server decides if the schedule exists, the cached schedules may be outdated
 	err = client.DeleteSchedule(&api.DeleteScheduleRequest{
 		ProgramID: progInfo.ID,
+		Name:      scheduleName,
 	})
 
 	if err != nil {
 		return err
 	}
+
+	if scheduleName != "" {
+		fmt.Printf("Successfully removed schedule '%s' from micro\n", scheduleName)
+		progInfo.RemoveSchedule(scheduleName)
+		runtimeManager.StoreProgInfo(progInfo)
+		return nil
+	}
 	fmt.Println("Successfully removed schedule from micro")
 
 	progInfo.Cron = ""
@@ -1,18 +1,16 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/deta/deta-cli/api"
-	"github.com/deta/deta-cli/runtime"
+	"github.com/deta/deta-cli/cron"
 	"github.com/spf13/cobra"
 )
 
 var (
-	errInvalidExp = errors.New("invalid expression")
+	errInvalidExp = cron.ErrInvalidExpression
 
 	cronSetCmd = &cobra.Command{
 		Use:     "set [path] <expression>",
@@ -42,7 +40,7 @@ func setCron(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no expression provided")
 	}
 
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	runtimeManager, err := newProfileManager(&wd, false)
 	if err != nil {
 		return err
 	}
@@ -53,12 +51,16 @@ func setCron(cmd *cobra.Command, args []string) error {
 	}
 
 	if !isInitialized {
-		return fmt.Errorf("no deta micro present in '%s'", wd)
+		return notInitializedError(wd)
 	}
 
+	expr, err = cron.Normalize(expr)
+	if err != nil {
+		return fmt.Errorf("%v, see `deta cron set --help`", err)
+	}
 	cronType, err := getCronTypeFromExpr(expr)
 	if err != nil {
-		return fmt.Errorf("invalid cron expression, see `deta cron set --help`")
+		return fmt.Errorf("%v, see `deta cron set --help`", err)
 	}
 
 	progInfo, err := runtimeManager.GetProgInfo()
@@ -86,15 +88,13 @@ func setCron(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// getCronTypeFromExpr validates the expression and gets its type, 'rate' or 'cron'
 func getCronTypeFromExpr(expr string) (string, error) {
-	parts := strings.Split(expr, " ")
-	if len(parts) == 2 {
-		return "rate", nil
-	}
-	if len(parts) == 6 {
-		return "cron", nil
+	_, err := cron.Parse(expr)
+	if err != nil {
+		return "", err
 	}
-	return "", errInvalidExp
+	return cron.Type(expr)
 }
 
 func setExamples() string {
@@ -16,7 +16,17 @@ func TestGetCronTypeFromExpr(t *testing.T) {
 		{"0 10 * * ? *", "cron", nil},
 		{"0/15 * * * ? *", "cron", nil},
 		{"0/5 8-17 ? * MON-FRI *", "cron", nil},
+		{"15 10 L * ? *", "cron", nil},
+		{"0 12 ? * 6#3 2021-2030", "cron", nil},
 		{"akdlkf", "", errInvalidExp},
+		{"banana split", "", errInvalidExp},
+		{"1 minutes", "", errInvalidExp},
+		{"5 hour", "", errInvalidExp},
+		{"0 10 * * * *", "", errInvalidExp},
+		{"60 10 * * ? *", "", errInvalidExp},
+		{"0 24 * * ? *", "", errInvalidExp},
+		{"0 10 32 * ? *", "", errInvalidExp},
+		{"0 10 ? FOO MON *", "", errInvalidExp},
 		{"0 10 * * ?", "", errInvalidExp},
 		{"a b c d e f g h", "", errInvalidExp},
 	}
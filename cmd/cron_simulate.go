@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/cron"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// default action of the invocations of cron simulate
+	defaultCronAction = "cron"
+)
+
+var (
+	cronSpeed         float64
+	cronAction        string
+	cronSimulateLocal bool
+
+	cronSimulateCmd = &cobra.Command{
+		Use:     "simulate [flags] [expression]",
+		Short:   "Run a deta micro at the next times of a schedule",
+		Args:    cobra.MaximumNArgs(1),
+		Example: simulateExamples(),
+		RunE:    simulateCron,
+	}
+)
+
+func init() {
+	cronSimulateCmd.Flags().IntVarP(&cronCount, "count", "n", defaultCronCount, "number of times to run the micro")
+	cronSimulateCmd.Flags().Float64Var(&cronSpeed, "speed", 1, "speed up the time between runs by this factor, 0 runs without waiting")
+	cronSimulateCmd.Flags().StringVar(&scheduleName, "name", "", "name of the schedule of the micro without an expression, the default schedule if empty")
+	cronSimulateCmd.Flags().StringVar(&cronAction, "action", defaultCronAction, "action to run the micro with, defaults to the action of the schedule with --name")
+	cronSimulateCmd.Flags().BoolVar(&cronSimulateLocal, "local", false, "run the micro served locally with deta dev")
+	cronSimulateCmd.Flags().IntVar(&devPort, "port", defaultDevPort, "port of the local server with --local")
+	cronSimulateCmd.Flags().BoolVarP(&showLogs, "logs", "l", false, "show micro logs")
+	cronCmd.AddCommand(cronSimulateCmd)
+}
+
+func simulateCron(cmd *cobra.Command, args []string) error {
+	if cronCount < 1 {
+		return fmt.Errorf("count must be a positive number")
+	}
+	if cronSpeed < 0 {
+		return fmt.Errorf("speed must not be negative")
+	}
+
+	var expr, programID, body string
+	action := cronAction
+	if len(args) != 0 {
+		expr = args[0]
+	}
+	if !cronSimulateLocal || expr == "" {
+		progInfo, err := getMicroInfo()
+		if err != nil {
+			return err
+		}
+		programID = progInfo.ID
+		if expr == "" {
+			s, err := getMicroSchedule(progInfo, scheduleName)
+			if err != nil {
+				return err
+			}
+			expr, body = s.Expression, s.Body
+			if scheduleName != "" && !cmd.Flags().Changed("action") {
+				action = s.Action
+			}
+		}
+	}
+
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("%v, see `deta cron set --help`", err)
+	}
+
+	now := time.Now().UTC()
+	times := cron.NextN(schedule, now, cronCount)
+	if len(times) == 0 {
+		fmt.Printf("Schedule '%s' does not run again\n", expr)
+		return nil
+	}
+
+	fmt.Printf("Simulating schedule '%s'...\n", expr)
+	prev := now
+	for i, t := range times {
+		if cronSpeed > 0 {
+			wait := time.Duration(float64(t.Sub(prev)) / cronSpeed)
+			fmt.Printf("Waiting %s for run at %s\n", wait.Round(time.Second), t.Format(time.RFC1123))
+			time.Sleep(wait)
+		}
+		prev = t
+
+		fmt.Println()
+		fmt.Printf("Run %d of %d at %s\n", i+1, len(times), t.Format(time.RFC1123))
+		req := &api.InvokeProgRequest{
+			ProgramID: programID,
+			Action:    action,
+			Body:      body,
+		}
+		var res *api.InvokeProgResponse
+		if cronSimulateLocal {
+			res, err = invokeDev(req)
+		} else {
+			res, err = client.InvokeProgram(req)
+		}
+		if err != nil {
+			return err
+		}
+		err = printResponse(res.Payload, res.Logs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func simulateExamples() string {
+	return `
+1. deta cron simulate --speed 0
+
+Run the micro in the current directory 5 times in a row, once for each of the next times of its schedule.
+
+2. deta cron simulate "1 hour" --count 3 --speed 60 --logs
+
+Run the micro in the current directory 3 times a minute apart, simulating a schedule of every hour 60 times faster, and show the logs.
+
+3. deta cron simulate "0/5 * * * ? *" --local --speed 0
+
+Run the micro served locally with deta dev for each of the next 5 times of the cron expression without waiting.
+
+4. deta cron simulate --name nightly-cleanup --count 1 --speed 0
+
+Run the micro in the current directory once with the action and input of its schedule 'nightly-cleanup'.`
+}
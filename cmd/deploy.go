@@ -1,15 +1,34 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 
 	"github.com/deta/deta-cli/api"
 	"github.com/deta/deta-cli/runtime"
 	"github.com/spf13/cobra"
 )
 
+const (
+	// maximum size of changes sent in a single deploy request
+	maxBatchSize = 4 * 1024 * 1024
+)
+
 var (
+	dryRun          bool
+	deployOutput    string
+	rehash          bool
+	hashConcurrency int
+	forceDeploy     bool
+	deployAll       bool
+	deployParallel  int
+
+	// ids of the micros checked for drift in this session
+	driftChecked sync.Map
+
 	deployCmd = &cobra.Command{
 		Use:     "deploy [path]",
 		Short:   "Deploy a deta micro",
@@ -20,14 +39,24 @@ var (
 )
 
 func init() {
+	deployCmd.Flags().BoolVar(&dryRun, "dry-run", false, "show the changes that would be deployed without deploying")
+	deployCmd.Flags().StringVarP(&deployOutput, "output", "o", textOutput, "output format of the dry run, 'text' or 'json'")
+	deployCmd.Flags().BoolVar(&rehash, "rehash", false, "hash all files to check for changes, even if unchanged since last deploy")
+	deployCmd.Flags().IntVar(&hashConcurrency, "hash-concurrency", 0, "max number of files hashed concurrently, defaults to the number of cpus")
+	deployCmd.Flags().BoolVarP(&forceDeploy, "force", "f", false, "deploy even if the deployed code changed since the last deploy or pull")
+	deployCmd.Flags().BoolVar(&deployAll, "all", false, "deploy all micros in the path and its sub directories")
+	deployCmd.Flags().IntVar(&deployParallel, "parallel", defaultParallel, "max number of micros deployed concurrently with --all")
+	deployCmd.PersistentFlags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
 	rootCmd.AddCommand(deployCmd)
 }
 
 func deploy(cmd *cobra.Command, args []string) error {
-	// check version
-	c := make(chan *checkVersionMsg, 1)
-	defer close(c)
-	go checkVersion(c)
+	if deployOutput != textOutput && deployOutput != jsonOutput {
+		return fmt.Errorf("unsupported output format '%s', see `deta deploy --help`", deployOutput)
+	}
+	if deployParallel < 1 {
+		return fmt.Errorf("invalid value %d for --parallel, must be at least 1", deployParallel)
+	}
 
 	wd, err := os.Getwd()
 	if err != nil {
@@ -37,33 +66,107 @@ func deploy(cmd *cobra.Command, args []string) error {
 		wd = args[0]
 	}
 
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	if dryRun {
+		if deployAll {
+			return fmt.Errorf("--dry-run can not be used with --all")
+		}
+		runtimeManager, progInfo, err := newDeployManager(wd)
+		if err != nil {
+			return err
+		}
+		return showDeployPlan(runtimeManager, progInfo)
+	}
+
+	// check version
+	c := make(chan *checkVersionMsg, 1)
+	defer close(c)
+	go checkVersion(c)
+
+	if deployAll {
+		err = deployAllMicros(wd)
+	} else {
+		err = deployMicro(wd, os.Stdout)
+	}
 	if err != nil {
 		return err
 	}
+	cm := <-c
+	if cm.err == nil && cm.isLower {
+		fmt.Println("New Deta CLI version available, upgrade with `deta version upgrade`")
+	}
+	return nil
+}
+
+// newDeployManager gets the runtime manager and program info of the micro in wd
+func newDeployManager(wd string) (*runtime.Manager, *runtime.ProgInfo, error) {
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	runtimeManager.SetRehash(rehash)
+	runtimeManager.SetConcurrency(hashConcurrency)
 
 	isInitialized, err := runtimeManager.IsInitialized()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	if !isInitialized {
-		return fmt.Errorf("no deta micro initilialized, see `deta new --help` to create a micro")
+		return nil, nil, notInitializedError(wd)
 	}
 
 	progInfo, err := runtimeManager.GetProgInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+	return runtimeManager, progInfo, nil
+}
+
+// deployMicro deploys the micro in wd writing the output to w
+func deployMicro(wd string, w io.Writer) error {
+	runtimeManager, progInfo, err := newDeployManager(wd)
 	if err != nil {
 		return err
 	}
-	err = deployChanges(runtimeManager, progInfo, false)
+
+	err = checkDrift(runtimeManager, progInfo, forceDeploy, w)
 	if err != nil {
 		return err
 	}
-	cm := <-c
-	if cm.err == nil && cm.isLower {
-		fmt.Println("New Deta CLI version available, upgrade with `deta version upgrade`")
+
+	err = updateProfileEnvs(runtimeManager, progInfo, w)
+	if err != nil {
+		return err
 	}
-	return nil
+	return deployChanges(runtimeManager, progInfo, false, w)
+}
+
+// updateProfileEnvs updates the env vars of the micro from the env file of the env profile
+// only if keys were added or removed, changed values are updated with `deta update`
+func updateProfileEnvs(m *runtime.Manager, p *runtime.ProgInfo, w io.Writer) error {
+	envFile, err := m.ProfileEnvFile()
+	if err != nil || envFile == "" {
+		return err
+	}
+	envChanges, err := m.GetEnvChanges(envFile)
+	if err != nil {
+		return fmt.Errorf("failed to read env file '%s': %v", envFile, err)
+	}
+	if envChanges == nil {
+		return nil
+	}
+
+	keysChanged := len(envChanges.Removed) > 0
+	for k := range envChanges.Vars {
+		if !inSlice(p.Envs, k) {
+			keysChanged = true
+		}
+	}
+	if !keysChanged {
+		return nil
+	}
+	fmt.Fprintf(w, "Updating environment variables from '%s'...\n", envFile)
+	return updateProgEnvs(m, p, envChanges)
 }
 
 // reloadDeps gets program details from the server and updates the prog info deps from prog details
@@ -84,7 +187,54 @@ func reloadDeps(m *runtime.Manager, p *runtime.ProgInfo) error {
 	return nil
 }
 
-func deployChanges(m *runtime.Manager, p *runtime.ProgInfo, isWatcher bool) error {
+// checkDrift checks if the deployed code changed since the last deploy or pull
+// to not overwrite deployments made from other places
+// with force the deployed code is stored as the state instead, so the next changes overwrite the drift
+// each micro is only checked once per session as the check downloads the deployed code
+func checkDrift(m *runtime.Manager, p *runtime.ProgInfo, force bool, w io.Writer) error {
+	if _, checked := driftChecked.Load(p.ID); checked {
+		return nil
+	}
+	o, err := client.DownloadProgram(&api.DownloadProgramRequest{
+		ProgramID: p.ID,
+		Runtime:   p.Runtime,
+		Account:   p.Account,
+		Region:    p.Region,
+	})
+	if err != nil {
+		return err
+	}
+
+	drift, err := m.Drift(o.ZipFile)
+	if err != nil {
+		return err
+	}
+	if len(drift) == 0 {
+		// later deploys of the session only overwrite code deployed by the session
+		driftChecked.Store(p.ID, struct{}{})
+		return nil
+	}
+
+	fmt.Fprintln(w, "Deployed code changed since the last deploy or pull:")
+	for _, path := range drift {
+		fmt.Fprintf(w, "  ~ %s\n", path)
+	}
+	fmt.Fprintln(w)
+	if force {
+		// files that differ from the deployed code are deployed with the next changes
+		// and files only present in the deployed code are deleted
+		_, err = m.Link(o.ZipFile)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "Overwriting changes of the deployed code")
+		driftChecked.Store(p.ID, struct{}{})
+		return nil
+	}
+	return fmt.Errorf("deploy aborted, see changes with `deta diff` and merge them with `deta pull`, or overwrite them with `deta deploy --force`")
+}
+
+func deployChanges(m *runtime.Manager, p *runtime.ProgInfo, isWatcher bool, w io.Writer) error {
 	c, err := m.GetChanges()
 	if err != nil {
 		return err
@@ -104,72 +254,50 @@ func deployChanges(m *runtime.Manager, p *runtime.ProgInfo, isWatcher bool) erro
 		// workaround for multiple write events fired
 		// with file watcher
 		if !isWatcher {
-			fmt.Println("Everything up to date")
+			fmt.Fprintln(w, "Everything up to date")
 		}
 		return nil
 	}
 
 	if c != nil {
-		fmt.Println("Deploying...")
-		_, err = client.Deploy(&api.DeployRequest{
-			ProgramID:   p.ID,
-			Changes:     c.Changes,
-			Deletions:   c.Deletions,
-			BinaryFiles: c.BinaryFiles,
-			Account:     p.Account,
-			Region:      p.Region,
-		})
+		fmt.Fprintln(w, "Deploying...")
+		err = uploadChanges(m, p, c, w)
 		if err != nil {
 			return err
 		}
 
 		msg := "Successfully deployed changes"
-		fmt.Println(msg)
-		m.StoreState()
+		fmt.Fprintln(w, msg)
+		storeSnapshot(m, w)
 	}
 
 	if dc != nil {
-		fmt.Println("Updating dependencies...")
-		command := runtime.DepCommands[p.RuntimeName]
+		fmt.Fprintln(w, "Updating dependencies...")
 		if len(dc.Removed) > 0 {
-			uninstallCmd := ""
-			// clean all deps if everything is removed
-			if areSlicesEqualNoOrder(dc.Removed, p.Deps) {
-				uninstallCmd = fmt.Sprintf("%s clean", command)
-			} else {
-				uninstallCmd = fmt.Sprintf("%s uninstall", command)
-				for _, d := range dc.Removed {
-					uninstallCmd = fmt.Sprintf("%s %s", uninstallCmd, d)
-				}
-			}
 			o, err := client.UpdateProgDeps(&api.UpdateProgDepsRequest{
 				ProgramID: p.ID,
-				Command:   uninstallCmd,
+				Command:   uninstallDepsCmd(dc, p),
 			})
 			if err != nil {
 				return err
 			}
-			fmt.Println(o.Output)
+			fmt.Fprintln(w, o.Output)
 			if o.HasError {
-				fmt.Println()
+				fmt.Fprintln(w)
 				return fmt.Errorf("failed to remove dependecies: error on one or more dependencies, no dependencies were removed, see output for details")
 			}
 		}
 		if len(dc.Added) > 0 {
-			installCmd := fmt.Sprintf("%s install", command)
-			for _, a := range dc.Added {
-				installCmd = fmt.Sprintf("%s %s", installCmd, a)
-			}
 			o, err := client.UpdateProgDeps(&api.UpdateProgDepsRequest{
 				ProgramID: p.ID,
-				Command:   installCmd,
+				Command:   installDepsCmd(dc, p),
 			})
 			if err != nil {
 				return err
 			}
-			fmt.Println(o.Output)
+			fmt.Fprintln(w, o.Output)
 			if o.HasError {
-				fmt.Println()
+				fmt.Fprintln(w)
 				return fmt.Errorf("failed to update dependecies: error on one or more dependencies, no dependencies were added, see output for details")
 			}
 		}
@@ -181,6 +309,95 @@ func deployChanges(m *runtime.Manager, p *runtime.ProgInfo, isWatcher bool) erro
 	return nil
 }
 
+// uploadChanges sends changes in batches of estimated size at most maxBatchSize
+// the state is updated after each batch so a failed deployment resumes from the last deployed batch
+func uploadChanges(m *runtime.Manager, p *runtime.ProgInfo, c *runtime.StateChanges, w io.Writer) error {
+	batches := c.Split(maxBatchSize)
+	for i := 0; i < len(batches); i++ {
+		b := batches[i]
+		if len(batches) > 1 {
+			fmt.Fprintf(w, "Uploading batch %d of %d (%s)...\n", i+1, len(batches), formatSize(b.Size()))
+		}
+		// only read contents of the files in the current batch
+		err := m.ReadContents(b)
+		if err != nil {
+			return err
+		}
+		_, err = client.Deploy(&api.DeployRequest{
+			ProgramID:   p.ID,
+			Changes:     b.Changes,
+			Deletions:   b.Deletions,
+			BinaryFiles: b.BinaryFiles,
+			Account:     p.Account,
+			Region:      p.Region,
+		})
+		if err != nil {
+			// split the batch further if it's still too large for the server
+			if errors.Is(err, api.ErrEntityTooLarge) {
+				if smaller := b.Split(b.Size() / 2); len(smaller) > 1 {
+					// smaller batches read their own contents
+					b.ReleaseContents()
+					batches = append(batches[:i], append(smaller, batches[i+1:]...)...)
+					i--
+					continue
+				}
+				err = fmt.Errorf("failed to deploy: %v", err)
+			}
+			if i > 0 {
+				return fmt.Errorf("%v\n%d of %d batches deployed, run `deta deploy` again to resume", err, i, len(batches))
+			}
+			return err
+		}
+
+		// only store state of files that were deployed
+		err = m.UpdateState(b)
+		if err != nil {
+			return err
+		}
+		// the snapshot of the deployment is stored from the contents as deployed
+		err = m.StoreObjects(b)
+		if err != nil {
+			fmt.Fprintf(w, "Failed to store deployed files in history: %v\n", err)
+		}
+		// contents of deployed batches are not needed anymore
+		b.ReleaseContents()
+		batches[i] = nil
+	}
+	return nil
+}
+
+// storeSnapshot stores the deployed files in the deployment history
+// failing to store the snapshot does not fail the deployment
+func storeSnapshot(m *runtime.Manager, w io.Writer) {
+	_, err := m.StoreSnapshot()
+	if err != nil {
+		fmt.Fprintf(w, "Failed to store deployment in history: %v\n", err)
+	}
+}
+
+// uninstallDepsCmd command to uninstall removed dependencies
+func uninstallDepsCmd(dc *runtime.DepChanges, p *runtime.ProgInfo) string {
+	command := runtime.DepCommands[p.RuntimeName]
+	// clean all deps if everything is removed
+	if areSlicesEqualNoOrder(dc.Removed, p.Deps) {
+		return fmt.Sprintf("%s clean", command)
+	}
+	uninstallCmd := fmt.Sprintf("%s uninstall", command)
+	for _, d := range dc.Removed {
+		uninstallCmd = fmt.Sprintf("%s %s", uninstallCmd, d)
+	}
+	return uninstallCmd
+}
+
+// installDepsCmd command to install added dependencies
+func installDepsCmd(dc *runtime.DepChanges, p *runtime.ProgInfo) string {
+	installCmd := fmt.Sprintf("%s install", runtime.DepCommands[p.RuntimeName])
+	for _, a := range dc.Added {
+		installCmd = fmt.Sprintf("%s %s", installCmd, a)
+	}
+	return installCmd
+}
+
 func deployExamples() string {
 	return `
 1. deta deploy
@@ -189,5 +406,38 @@ Deploy a deta micro rooted in the current directory.
 
 2. deta deploy micros/my-micro-1
 
-Deploy a deta micro rooted in 'micros/my-micro-1' directory.`
+Deploy a deta micro rooted in 'micros/my-micro-1' directory.
+
+3. deta deploy --dry-run
+
+Show the files and dependencies that would be deployed without deploying.
+
+4. deta deploy --dry-run --output json
+
+Show the deployment plan as json.
+
+5. deta deploy --force
+
+Deploy even if the deployed code changed since the last deploy or pull
+from the current directory, overwriting the changes.
+
+6. deta deploy --all micros
+
+Deploy all micros in 'micros' and its sub directories, at most 4 at the same time.
+
+7. deta deploy --all --parallel 8
+
+Deploy all micros in the current directory and its sub directories, at most 8 at the same time.
+
+8. deta deploy history
+
+Show the deployment history of the deta micro rooted in the current directory.
+
+9. deta deploy rollback
+
+Rollback the deta micro rooted in the current directory to the previous deployment.
+
+10. deta deploy --env-profile staging
+
+Deploy the deta micro of the env profile 'staging' rooted in the current directory, see ` + "`deta new --help`" + `.`
 }
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/deta/deta-cli/runtime"
+)
+
+const (
+	// default max number of micros deployed concurrently with --all
+	defaultParallel = 4
+)
+
+// microResult result of running a command for a micro
+type microResult struct {
+	name string
+	err  error
+}
+
+// microName gets the name of a micro dir shown in the output, the path relative to root
+func microName(root, dir string) string {
+	name, err := filepath.Rel(root, dir)
+	if err != nil || name == "." {
+		return filepath.Base(dir)
+	}
+	return name
+}
+
+// findMicros finds the root dirs of the micros in root
+func findMicros(root string) ([]string, error) {
+	dirs, err := runtime.FindMicros(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no deta micros initialized in '%s' or its sub directories", root)
+	}
+	return dirs, nil
+}
+
+// runMicros runs fn for each micro dir with at most parallel micros at the same time
+// output of each micro is buffered and printed once fn returns
+func runMicros(root string, dirs []string, parallel int, fn func(dir string, w io.Writer) error) []*microResult {
+	results := make([]*microResult, len(dirs))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i, dir := range dirs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, dir string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var buf bytes.Buffer
+			err := fn(dir, &buf)
+			results[i] = &microResult{
+				name: microName(root, dir),
+				err:  err,
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			printMicroOutput(results[i], buf.Bytes())
+		}(i, dir)
+	}
+	wg.Wait()
+	return results
+}
+
+// printMicroOutput prints the output of a micro under its name
+func printMicroOutput(r *microResult, output []byte) {
+	fmt.Printf("==> %s\n", r.name)
+	os.Stdout.Write(output)
+	if r.err != nil {
+		fmt.Printf("Error: %v\n", r.err)
+	}
+	fmt.Println()
+}
+
+// formatMicroSummary formats the result of each micro, returns the number of failed micros
+func formatMicroSummary(results []*microResult) (string, int) {
+	var width int
+	for _, r := range results {
+		if len(r.name) > width {
+			width = len(r.name)
+		}
+	}
+
+	var b strings.Builder
+	var failed int
+	b.WriteString("Summary:\n")
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			// only the first line of the error, the full error is in the output of the micro
+			msg := strings.SplitN(r.err.Error(), "\n", 2)[0]
+			fmt.Fprintf(&b, "  %-*s  failed: %s\n", width, r.name, msg)
+			continue
+		}
+		fmt.Fprintf(&b, "  %-*s  ok\n", width, r.name)
+	}
+	return b.String(), failed
+}
+
+// deployAllMicros deploys all micros in root and its sub dirs concurrently
+func deployAllMicros(root string) error {
+	dirs, err := findMicros(root)
+	if err != nil {
+		return err
+	}
+
+	results := runMicros(root, dirs, deployParallel, deployMicro)
+	summary, failed := formatMicroSummary(results)
+	fmt.Print(summary)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d micros failed to deploy", failed, len(results))
+	}
+	return nil
+}
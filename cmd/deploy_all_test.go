@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRunMicros(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	var dirs []string
+	for i := 0; i < 10; i++ {
+		dirs = append(dirs, filepath.Join(root, "micros", fmt.Sprintf("micro-%d", i)))
+	}
+
+	var running, maxRunning int32
+	results := runMicros(root, dirs, 3, func(dir string, w io.Writer) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		fmt.Fprintln(w, "Deploying...")
+		if filepath.Base(dir) == "micro-3" {
+			return errors.New("failed to deploy\nsecond line")
+		}
+		return nil
+	})
+
+	assert.Assert(t, maxRunning <= 3)
+	assert.Equal(t, len(dirs), len(results))
+	for i, r := range results {
+		assert.Equal(t, filepath.Join("micros", fmt.Sprintf("micro-%d", i)), r.name)
+	}
+
+	summary, failed := formatMicroSummary(results[2:5])
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, fmt.Sprintf(`Summary:
+  %[1]s  ok
+  %[2]s  failed: failed to deploy
+  %[3]s  ok
+`, results[2].name, results[3].name, results[4].name), summary)
+}
+
+func TestMicrosForPath(t *testing.T) {
+	watched := map[string][]string{
+		filepath.FromSlash("/repo/a"):      {filepath.FromSlash("/repo/a")},
+		filepath.FromSlash("/repo/ab"):     {filepath.FromSlash("/repo/ab")},
+		filepath.FromSlash("/repo/shared"): {filepath.FromSlash("/repo/ab"), filepath.FromSlash("/repo/a")},
+	}
+	testCases := []struct {
+		path   string
+		micros []string
+	}{
+		{"/repo/a/main.py", []string{"/repo/a"}},
+		{"/repo/ab/main.py", []string{"/repo/ab"}},
+		{"/repo/a", []string{"/repo/a"}},
+		{"/repo/shared/utils.py", []string{"/repo/a", "/repo/ab"}},
+		{"/repo/abc/main.py", []string{}},
+		{"/repo/main.py", []string{}},
+	}
+	for _, tc := range testCases {
+		expected := make([]string, 0, len(tc.micros))
+		for _, m := range tc.micros {
+			expected = append(expected, filepath.FromSlash(m))
+		}
+		assert.DeepEqual(t, expected, microsForPath(watched, filepath.FromSlash(tc.path)))
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyCmd = &cobra.Command{
+		Use:     "history [path]",
+		Short:   "Show the local deployment history of a deta micro",
+		Args:    cobra.MaximumNArgs(1),
+		Example: historyExamples(),
+		RunE:    history,
+	}
+)
+
+func init() {
+	deployCmd.AddCommand(historyCmd)
+}
+
+func history(cmd *cobra.Command, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		wd = args[0]
+	}
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return err
+	}
+	if !isInitialized {
+		return notInitializedError(wd)
+	}
+
+	snapshots, err := runtimeManager.GetHistory()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return runtime.ErrNoHistory
+	}
+
+	for i, s := range snapshots {
+		fmt.Printf("%d\t%s\t%d files\n", i, s.DeployedAt.Local().Format("2006-01-02 15:04:05"), len(s.Files))
+	}
+	return nil
+}
+
+func historyExamples() string {
+	return `
+1. deta deploy history
+
+Show the deployments made from the current directory, latest first.
+The number before each deployment is used with 'deta deploy rollback'.`
+}
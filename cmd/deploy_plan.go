@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/deta/deta-cli/runtime"
+)
+
+// planFile a file in a deployment plan
+type planFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Binary bool   `json:"binary"`
+}
+
+// deployPlan changes that would be deployed
+type deployPlan struct {
+	Micro       string      `json:"micro"`
+	Added       []*planFile `json:"added"`
+	Modified    []*planFile `json:"modified"`
+	Deleted     []string    `json:"deleted"`
+	Install     []string    `json:"install"`
+	Uninstall   []string    `json:"uninstall"`
+	DepCommands []string    `json:"dependency_commands"`
+	PayloadSize int64       `json:"payload_size"` // estimated from the file sizes
+}
+
+// isEmpty checks if the plan has nothing to deploy
+func (d *deployPlan) isEmpty() bool {
+	return len(d.Added) == 0 && len(d.Modified) == 0 && len(d.Deleted) == 0 &&
+		len(d.Install) == 0 && len(d.Uninstall) == 0
+}
+
+// getDeployPlan gets the deployment plan from the state and dependency changes
+// the payload size is estimated from the file metadata, contents are not read
+// uses the cached program info for dependencies, does not call the api
+func getDeployPlan(c *runtime.StateChanges, dc *runtime.DepChanges, p *runtime.ProgInfo) *deployPlan {
+	plan := &deployPlan{
+		Micro:       p.Name,
+		Added:       make([]*planFile, 0),
+		Modified:    make([]*planFile, 0),
+		Deleted:     make([]string, 0),
+		Install:     make([]string, 0),
+		Uninstall:   make([]string, 0),
+		DepCommands: make([]string, 0),
+	}
+
+	if c != nil {
+		additions := make(map[string]struct{}, len(c.Additions))
+		for _, a := range c.Additions {
+			additions[a] = struct{}{}
+		}
+		for path, f := range c.Files {
+			pf := &planFile{
+				Path:   path,
+				Size:   f.Size,
+				Binary: f.Binary,
+			}
+			if _, ok := additions[path]; ok {
+				plan.Added = append(plan.Added, pf)
+			} else {
+				plan.Modified = append(plan.Modified, pf)
+			}
+		}
+		plan.Deleted = append(plan.Deleted, c.Deletions...)
+		plan.PayloadSize = c.Size()
+	}
+
+	if dc != nil {
+		if len(dc.Removed) > 0 {
+			plan.Uninstall = append(plan.Uninstall, dc.Removed...)
+			plan.DepCommands = append(plan.DepCommands, uninstallDepsCmd(dc, p))
+		}
+		if len(dc.Added) > 0 {
+			plan.Install = append(plan.Install, dc.Added...)
+			plan.DepCommands = append(plan.DepCommands, installDepsCmd(dc, p))
+		}
+	}
+
+	sortPlanFiles(plan.Added)
+	sortPlanFiles(plan.Modified)
+	sort.Strings(plan.Deleted)
+	return plan
+}
+
+func sortPlanFiles(files []*planFile) {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+}
+
+// showDeployPlan prints the deployment plan without deploying
+func showDeployPlan(m *runtime.Manager, p *runtime.ProgInfo) error {
+	c, err := m.GetChanges()
+	if err != nil {
+		return err
+	}
+
+	dc, err := m.GetDepChanges()
+	if err != nil {
+		return err
+	}
+
+	plan := getDeployPlan(c, dc, p)
+
+	if deployOutput == jsonOutput {
+		output, err := prettyPrint(plan)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+		return nil
+	}
+
+	if plan.isEmpty() {
+		fmt.Println("Everything up to date")
+		return nil
+	}
+
+	fmt.Printf("Deployment plan for micro '%s':\n", plan.Micro)
+	printPlanFiles("Added files:", "+", plan.Added)
+	printPlanFiles("Modified files:", "~", plan.Modified)
+	if len(plan.Deleted) > 0 {
+		fmt.Println()
+		fmt.Println("Deleted files:")
+		for _, d := range plan.Deleted {
+			fmt.Printf("  - %s\n", d)
+		}
+	}
+	if len(plan.DepCommands) > 0 {
+		fmt.Println()
+		fmt.Println("Dependencies:")
+		for _, u := range plan.Uninstall {
+			fmt.Printf("  - %s\n", u)
+		}
+		for _, i := range plan.Install {
+			fmt.Printf("  + %s\n", i)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("Payload size: %s\n", formatSize(plan.PayloadSize))
+	fmt.Println("Dry run, nothing was deployed")
+	return nil
+}
+
+func printPlanFiles(title, symbol string, files []*planFile) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println(title)
+	for _, f := range files {
+		fileType := "text"
+		if f.Binary {
+			fileType = "binary"
+		}
+		fmt.Printf("  %s %s (%s, %s)\n", symbol, f.Path, formatSize(f.Size), fileType)
+	}
+}
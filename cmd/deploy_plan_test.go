@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/deta/deta-cli/runtime"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetDeployPlan(t *testing.T) {
+	c := &runtime.StateChanges{
+		Files: map[string]*runtime.FileMeta{
+			"main.py":  {Size: 14},
+			"utils.py": {Size: 0},
+			"logo.png": {Size: 4, Binary: true},
+		},
+		Deletions: []string{"old.py"},
+		Additions: []string{"utils.py", "logo.png"},
+	}
+	dc := &runtime.DepChanges{
+		Added:   []string{"fastapi"},
+		Removed: []string{"flask"},
+	}
+	p := &runtime.ProgInfo{
+		ID:          "prog-id",
+		Name:        "my-micro",
+		RuntimeName: runtime.Python,
+		Deps:        []string{"flask", "requests"},
+	}
+
+	plan := getDeployPlan(c, dc, p)
+	assert.DeepEqual(t, plan.Added, []*planFile{
+		{Path: "logo.png", Size: 4, Binary: true},
+		{Path: "utils.py", Size: 0},
+	})
+	assert.DeepEqual(t, plan.Modified, []*planFile{
+		{Path: "main.py", Size: 14},
+	})
+	assert.DeepEqual(t, plan.Deleted, []string{"old.py"})
+	assert.DeepEqual(t, plan.DepCommands, []string{"pip uninstall flask", "pip install fastapi"})
+	// estimated without reading contents, binary files with their base64 encoded size
+	assert.Equal(t, plan.PayloadSize, int64(len(`"main.py"`)+14+len(`"utils.py"`)+len(`"logo.png"`)+8+len(`"old.py"`)))
+	assert.Assert(t, !plan.isEmpty())
+
+	plan = getDeployPlan(nil, nil, p)
+	assert.Assert(t, plan.isEmpty())
+}
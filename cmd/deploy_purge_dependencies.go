@@ -31,7 +31,7 @@ func purgeDeps(cmd *cobra.Command, args []string) error {
 	if len(args) != 0 {
 		wd = args[0]
 	}
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	runtimeManager, err := newProfileManager(&wd, false)
 	if err != nil {
 		return err
 	}
@@ -41,7 +41,7 @@ func purgeDeps(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if !isInitialized {
-		return fmt.Errorf("no deta micro initialized in '%s'", wd)
+		return notInitializedError(wd)
 	}
 
 	progInfo, err := runtimeManager.GetProgInfo()
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	forceRollback bool
+
+	rollbackCmd = &cobra.Command{
+		Use:     "rollback [path] [n]",
+		Short:   "Rollback a deta micro to a previous deployment",
+		Args:    cobra.MaximumNArgs(2),
+		Example: rollbackExamples(),
+		RunE:    rollback,
+	}
+)
+
+func init() {
+	rollbackCmd.Flags().BoolVarP(&forceRollback, "force", "f", false, "rollback without asking for approval, even if the deployed code changed since the last deploy or pull")
+	deployCmd.AddCommand(rollbackCmd)
+}
+
+func rollback(cmd *cobra.Command, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	n := 1
+	if len(args) == 2 {
+		wd = args[0]
+		n, err = strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid deployment number '%s', see `deta deploy history`", args[1])
+		}
+	} else if len(args) == 1 {
+		// a single arg is the deployment number if it's a number, the path otherwise
+		// a dir named like a number is passed as a relative path, e.g. './2'
+		if i, err := strconv.Atoi(args[0]); err == nil {
+			n = i
+		} else {
+			wd = args[0]
+		}
+	}
+	if n < 0 {
+		return fmt.Errorf("invalid deployment number '%d', see `deta deploy history`", n)
+	}
+
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return err
+	}
+	if !isInitialized {
+		return notInitializedError(wd)
+	}
+
+	progInfo, err := runtimeManager.GetProgInfo()
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := runtimeManager.GetHistory()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return runtime.ErrNoHistory
+	}
+	if n >= len(snapshots) {
+		return fmt.Errorf("deployment %d not found in history, only %d deployments present", n, len(snapshots))
+	}
+	snapshot := snapshots[n]
+
+	err = checkDrift(runtimeManager, progInfo, forceRollback, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	c, err := runtimeManager.GetSnapshotChanges(snapshot)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		fmt.Println("Everything up to date")
+		return nil
+	}
+
+	fmt.Printf("Rolling back to deployment of %s\n", snapshot.DeployedAt.Local().Format("2006-01-02 15:04:05"))
+	var added, modified []*planFile
+	for path, f := range c.Files {
+		pf := &planFile{
+			Path:   path,
+			Size:   f.Size,
+			Binary: f.Binary,
+		}
+		if inSlice(c.Additions, path) {
+			added = append(added, pf)
+		} else {
+			modified = append(modified, pf)
+		}
+	}
+	sortPlanFiles(added)
+	sortPlanFiles(modified)
+	printPlanFiles("Added files:", "+", added)
+	printPlanFiles("Modified files:", "~", modified)
+	if len(c.Deletions) > 0 {
+		fmt.Println()
+		fmt.Println("Deleted files:")
+		for _, d := range c.Deletions {
+			fmt.Printf("  - %s\n", d)
+		}
+	}
+	fmt.Println()
+
+	if !forceRollback {
+		fmt.Println("Local files and dependencies are not changed. Continue? [y/n]")
+		var cont string
+		fmt.Scanf("%s", &cont)
+		if strings.ToLower(cont) != "y" {
+			fmt.Println("Rollback aborted")
+			return nil
+		}
+	}
+
+	fmt.Println("Deploying...")
+	err = uploadChanges(runtimeManager, progInfo, c, os.Stdout)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Successfully rolled back")
+	storeSnapshot(runtimeManager, os.Stdout)
+	return nil
+}
+
+func rollbackExamples() string {
+	return `
+1. deta deploy rollback
+
+Rollback the deta micro in the current directory to the previous deployment.
+Only the deployed code is rolled back, local files and dependencies are not changed.
+
+2. deta deploy rollback 3
+
+Rollback to the deployment numbered 3 in 'deta deploy history'.
+A single number is always read as the deployment number, pass a directory named like a number as './3'.
+
+3. deta deploy rollback ./my-micro 2 --force
+
+Rollback the deta micro in './my-micro' to the deployment numbered 2 without asking for approval.`
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keepLocal      bool
+	destroyConfirm string
+
+	destroyCmd = &cobra.Command{
+		Use:     "destroy [path]",
+		Short:   "Delete a deta micro",
+		Args:    cobra.MaximumNArgs(1),
+		Example: destroyExamples(),
+		RunE:    destroy,
+	}
+)
+
+func init() {
+	destroyCmd.Flags().BoolVar(&keepLocal, "keep-local", false, "keep the info about the micro in the directory")
+	destroyCmd.Flags().StringVar(&destroyConfirm, "confirm", "", "name of the micro to confirm the deletion without asking")
+	destroyCmd.Flags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
+	rootCmd.AddCommand(destroyCmd)
+}
+
+func destroy(cmd *cobra.Command, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		wd = args[0]
+	}
+
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return err
+	}
+	if !isInitialized {
+		return notInitializedError(wd)
+	}
+
+	progInfo, err := runtimeManager.GetProgInfo()
+	if err != nil {
+		return err
+	}
+
+	if destroyConfirm == "" {
+		fmt.Printf("The micro '%s' and its deployed code will be deleted permanently, local files are not changed.\n", progInfo.Name)
+	}
+	confirmed, err := confirmName(progInfo.Name, destroyConfirm)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Name does not match, destroy aborted")
+		return nil
+	}
+
+	fmt.Println("Deleting micro...")
+	err = client.DeleteProgram(&api.DeleteProgramRequest{
+		ProgramID: progInfo.ID,
+		Account:   progInfo.Account,
+		Region:    progInfo.Region,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !keepLocal {
+		err = runtimeManager.Unlink()
+		if err != nil {
+			return fmt.Errorf("deleted micro but failed to remove its info from '%s': %v", wd, err)
+		}
+	}
+	fmt.Printf("Successfully deleted micro '%s'\n", progInfo.Name)
+	return nil
+}
+
+func destroyExamples() string {
+	return `
+1. deta destroy
+
+Delete the deta micro rooted in the current directory after typing its name to confirm.
+The info about the micro is removed from the directory, local files are not changed.
+
+2. deta destroy --confirm my-micro micros/my-micro
+
+Delete the deta micro 'my-micro' rooted in './micros/my-micro' without asking for confirmation.
+
+3. deta destroy --keep-local
+
+Delete the deta micro rooted in the current directory keeping the info about the micro in the directory.
+
+4. deta destroy --env-profile staging
+
+Delete the deta micro of the env profile 'staging' rooted in the current directory.`
+}
@@ -5,7 +5,6 @@ import (
 	"os"
 
 	"github.com/deta/deta-cli/api"
-	"github.com/deta/deta-cli/runtime"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +18,8 @@ var (
 )
 
 func init() {
+	detailsCmd.Flags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
+	detailsCmd.Flags().StringVar(&spaceFlag, "space", "", progSpaceUsage)
 	rootCmd.AddCommand(detailsCmd)
 }
 
@@ -30,7 +31,7 @@ func details(cmd *cobra.Command, args []string) error {
 	if len(args) != 0 {
 		wd = args[0]
 	}
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	runtimeManager, err := newProfileManager(&wd, false)
 	if err != nil {
 		return err
 	}
@@ -39,7 +40,7 @@ func details(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if !isInitialzied {
-		return fmt.Errorf("no deta micro initialized in '%s'", wd)
+		return notInitializedError(wd)
 	}
 	progInfo, err := runtimeManager.GetProgInfo()
 	if err != nil {
@@ -48,7 +49,7 @@ func details(cmd *cobra.Command, args []string) error {
 	if progInfo == nil {
 		return fmt.Errorf("failed to get deta micro details")
 	}
-	u, err := getUserInfo(runtimeManager, client)
+	u, err := getProgUserInfo(runtimeManager, progInfo)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/rjeczalik/notify"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// default port of the local server of deta dev
+	defaultDevPort = 4200
+)
+
+var (
+	devPort    int
+	devEnvFile string
+
+	devCmd = &cobra.Command{
+		Use:     "dev [flags] [path]",
+		Short:   "Serve a deta micro locally",
+		Args:    cobra.MaximumNArgs(1),
+		Example: devExamples(),
+		RunE:    dev,
+	}
+)
+
+func init() {
+	devCmd.Flags().IntVarP(&devPort, "port", "p", defaultDevPort, "port of the local server")
+	devCmd.Flags().StringVarP(&devEnvFile, "env", "e", "", "path to env file, defaults to the env file of the manifest or '.env' if present")
+	rootCmd.AddCommand(devCmd)
+}
+
+func dev(cmd *cobra.Command, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		wd = args[0]
+	}
+
+	runtimeManager, err := runtime.NewManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	envFile, err := getDevEnvFile(runtimeManager, wd)
+	if err != nil {
+		return err
+	}
+
+	c := make(chan notify.EventInfo, 1)
+
+	includeDirs, err := runtimeManager.IncludeDirs()
+	if err != nil {
+		return err
+	}
+	// {dir}/... watch dir recursively
+	for _, dir := range append([]string{wd}, includeDirs...) {
+		if err := notify.Watch(filepath.Join(dir, "..."), c, notify.All); err != nil {
+			return err
+		}
+	}
+	defer notify.Stop(c)
+
+	// only changes of files deployed with the micro reload the micro
+	changes := make(chan struct{}, 1)
+	go func() {
+		for e := range c {
+			isChange, err := runtimeManager.IsDevChange(e.Path(), envFile)
+			if err != nil {
+				os.Stderr.WriteString(fmt.Sprintf("Failed to check change of '%s': %v\n", e.Path(), err))
+				continue
+			}
+			if isChange {
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	for {
+		devProc, err := runtimeManager.DevCommand(envFile, devPort)
+		if err != nil {
+			return err
+		}
+		devProc.Stdout = os.Stdout
+		devProc.Stderr = os.Stderr
+		err = devProc.Start()
+		if err != nil {
+			var execErr *exec.Error
+			if errors.As(err, &execErr) {
+				return fmt.Errorf("failed to start micro, '%s' not found, install the toolchain of the runtime", devProc.Path)
+			}
+			return err
+		}
+		fmt.Printf("Serving micro at http://localhost:%d\n", devPort)
+		if envFile != "" {
+			fmt.Printf("Loaded env vars from '%s'\n", envFile)
+		}
+
+		exited := make(chan error, 1)
+		go func() {
+			exited <- devProc.Wait()
+		}()
+
+		select {
+		case <-sigs:
+			devProc.Process.Kill()
+			<-exited
+			return nil
+		case err := <-exited:
+			fmt.Printf("Micro exited: %v\n", err)
+			fmt.Println("Waiting for changes to reload")
+			select {
+			case <-sigs:
+				return nil
+			case <-changes:
+			}
+		case <-changes:
+			devProc.Process.Kill()
+			<-exited
+		}
+
+		// wait for more changes of the same save
+		time.Sleep(100 * time.Millisecond)
+		select {
+		case <-changes:
+		default:
+		}
+		fmt.Println("Reloading...")
+	}
+}
+
+// getDevEnvFile gets the env file of deta dev, empty if no env file is used
+func getDevEnvFile(m *runtime.Manager, wd string) (string, error) {
+	if devEnvFile != "" {
+		return devEnvFile, nil
+	}
+	manifest, err := m.GetManifest()
+	if err != nil {
+		return "", err
+	}
+	if manifest != nil && manifest.Env != "" {
+		return manifest.Env, nil
+	}
+	if _, err := os.Stat(filepath.Join(wd, ".env")); err == nil {
+		return ".env", nil
+	}
+	return "", nil
+}
+
+// invokeDev invokes the micro served locally with deta dev like the invocations api
+func invokeDev(req *api.InvokeProgRequest) (*api.InvokeProgResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("http://localhost:%d%s", devPort, runtime.DevRunPath)
+	res, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run local micro, is it served with `deta dev --port %d`? %v", devPort, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to run local micro: %s", res.Status)
+	}
+
+	var resp api.InvokeProgResponse
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run local micro: %v", err)
+	}
+	return &resp, nil
+}
+
+func devExamples() string {
+	return `
+1. deta dev
+
+Serve the deta micro in the current directory at http://localhost:4200 using the local python or node toolchain.
+The app of 'main.py' or 'index.js' is served and reloaded when files of the micro change.
+Dependencies must be installed locally, e.g. with 'pip install -r requirements.txt' or 'npm install'.
+ASGI apps, e.g. of FastAPI, are served with uvicorn, install it with 'pip install uvicorn'.
+Include dirs of the manifest are watched too and can be imported at their prefixes.
+
+2. deta dev --port 8080 --env .env.local micros/my-micro
+
+Serve the deta micro in './micros/my-micro' at http://localhost:8080 with env vars from '.env.local'.
+
+3. deta run --local greet -- --name Jimmy
+
+Run the action 'greet' of the micro served locally with deta dev.`
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// max width of the bar of changed lines with --stat
+	maxStatWidth = 40
+)
+
+var (
+	diffNameOnly bool
+	diffStat     bool
+
+	diffCmd = &cobra.Command{
+		Use:     "diff [path]",
+		Short:   "Show changes between the deployed code and local files of a deta micro",
+		Args:    cobra.MaximumNArgs(1),
+		Example: diffExamples(),
+		RunE:    diff,
+	}
+)
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "only show the paths of changed files")
+	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "only show the number of changed lines of each file")
+	diffCmd.Flags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
+	rootCmd.AddCommand(diffCmd)
+}
+
+func diff(cmd *cobra.Command, args []string) error {
+	if diffNameOnly && diffStat {
+		return fmt.Errorf("only one of --name-only and --stat can be used")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		wd = args[0]
+	}
+
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return err
+	}
+	if !isInitialized {
+		return notInitializedError(wd)
+	}
+
+	progInfo, err := runtimeManager.GetProgInfo()
+	if err != nil {
+		return err
+	}
+
+	o, err := client.DownloadProgram(&api.DownloadProgramRequest{
+		ProgramID: progInfo.ID,
+		Runtime:   progInfo.Runtime,
+		Account:   progInfo.Account,
+		Region:    progInfo.Region,
+	})
+	if err != nil {
+		return err
+	}
+
+	diffs, err := runtimeManager.Diff(o.ZipFile)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		fmt.Println("No differences with the deployed code")
+		return nil
+	}
+
+	switch {
+	case diffNameOnly:
+		for _, d := range diffs {
+			fmt.Println(d.Path)
+		}
+	case diffStat:
+		fmt.Print(formatDiffStat(diffs))
+	default:
+		for _, d := range diffs {
+			fmt.Printf("diff %s\n", d.Path)
+			fmt.Print(d.Patch)
+		}
+	}
+	return nil
+}
+
+// formatDiffStat formats the number of changed lines of each file with a summary
+func formatDiffStat(diffs []*runtime.FileDiff) string {
+	var pathWidth, maxChanges, additions, deletions int
+	for _, d := range diffs {
+		if len(d.Path) > pathWidth {
+			pathWidth = len(d.Path)
+		}
+		if changes := d.Additions + d.Deletions; changes > maxChanges {
+			maxChanges = changes
+		}
+		additions += d.Additions
+		deletions += d.Deletions
+	}
+
+	var b strings.Builder
+	for _, d := range diffs {
+		if d.Binary {
+			fmt.Fprintf(&b, " %-*s | Bin\n", pathWidth, d.Path)
+			continue
+		}
+		plus, minus := d.Additions, d.Deletions
+		// scale the bar down if the largest change does not fit
+		if maxChanges > maxStatWidth {
+			plus = (plus*maxStatWidth + maxChanges - 1) / maxChanges
+			minus = (minus*maxStatWidth + maxChanges - 1) / maxChanges
+		}
+		fmt.Fprintf(&b, " %-*s | %d %s%s\n", pathWidth, d.Path, d.Additions+d.Deletions,
+			strings.Repeat("+", plus), strings.Repeat("-", minus))
+	}
+
+	files := "files"
+	if len(diffs) == 1 {
+		files = "file"
+	}
+	fmt.Fprintf(&b, " %d %s changed, %d insertions(+), %d deletions(-)\n", len(diffs), files, additions, deletions)
+	return b.String()
+}
+
+func diffExamples() string {
+	return `
+1. deta diff
+
+Show a unified diff of each file that differs between the deployed code 
+and the files in the current directory. Files in .detaignore are not compared.
+
+2. deta diff --name-only
+
+Only show the paths of the files that differ.
+
+3. deta diff --stat
+
+Show the number of changed lines of each file that differs.`
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	linkCmd = &cobra.Command{
+		Use:     "link [flags] [path]",
+		Short:   "Link a directory to an existing deta micro",
+		RunE:    link,
+		Example: linkExamples(),
+		Args:    cobra.MaximumNArgs(1),
+	}
+)
+
+func init() {
+	linkCmd.Flags().StringVar(&progName, "name", "", "deta micro name")
+	linkCmd.Flags().StringVar(&projectName, "project", "", "project of the micro")
+	linkCmd.Flags().StringVar(&spaceFlag, "space", "", spaceUsage)
+	linkCmd.Flags().StringVar(&envProfile, "env-profile", "", "link the micro to an env profile of the directory")
+	linkCmd.MarkFlagRequired("name")
+
+	rootCmd.AddCommand(linkCmd)
+}
+
+func link(cmd *cobra.Command, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		wd = args[0]
+	}
+
+	i, err := os.Stat(wd)
+	if err != nil {
+		return err
+	}
+	if !i.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", wd)
+	}
+
+	runtimeManager, err := newProfileManager(&wd, false)
+	if err != nil {
+		return err
+	}
+
+	isInitialized, err := runtimeManager.IsInitialized()
+	if err != nil {
+		return err
+	}
+	if isInitialized {
+		if envProfile != "" {
+			return fmt.Errorf("a deta micro already linked to env profile '%s' in '%s'", envProfile, wd)
+		}
+		return fmt.Errorf("a deta micro already present in '%s', see `deta link --help` to link another micro with an env profile", wd)
+	}
+
+	u, err := getUserInfo(runtimeManager, client)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		projectName = u.DefaultProject
+	}
+
+	progDetails, err := client.GetProgDetails(&api.GetProgDetailsRequest{
+		Program: progName,
+		Project: projectName,
+		Space:   u.DefaultSpace,
+	})
+	if err != nil {
+		return err
+	}
+
+	progInfo := progInfoFromDetails(progDetails, "")
+	setProgSchedules(progInfo)
+
+	// the entrypoint file in the directory must be of the runtime of the micro
+	progRuntime, err := runtime.CheckRuntime(progInfo.Runtime)
+	if err != nil {
+		return err
+	}
+	dirRuntime, err := runtimeManager.GetRuntime()
+	if err != nil && !errors.Is(err, runtime.ErrNoEntrypoint) {
+		return err
+	}
+	if dirRuntime != nil && dirRuntime.Name != progRuntime.Name {
+		return fmt.Errorf("'%s' contains a %s entrypoint file but micro '%s' has the %s runtime", wd, dirRuntime.Name, progInfo.Name, progRuntime.Name)
+	}
+
+	fmt.Println("Linking...")
+	o, err := client.DownloadProgram(&api.DownloadProgramRequest{
+		ProgramID: progInfo.ID,
+		Runtime:   progInfo.Runtime,
+		Account:   progInfo.Account,
+		Region:    progInfo.Region,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = runtimeManager.StoreProgInfo(progInfo)
+	if err != nil {
+		return err
+	}
+	// stores the deployed code as the state so that only differences are deployed
+	differ, err := runtimeManager.Link(o.ZipFile)
+	if err != nil {
+		return err
+	}
+
+	printPullFiles("Files that differ from the deployed code:", "~", differ)
+	if len(differ) > 0 {
+		fmt.Println()
+		fmt.Println("Differences will be deployed on the next deploy, see `deta diff` to show them")
+	}
+	fmt.Printf("Successfully linked '%s' to deta micro '%s'\n", wd, progInfo.Name)
+	return nil
+}
+
+func linkExamples() string {
+	return `
+1. deta link --name my-micro
+
+Link the current directory to the existing micro 'my-micro' from 'default' project.
+Files are not changed, only files that differ from the deployed code are deployed on the next deploy.
+
+2. deta link --name my-micro --project my-project micros/my-micro-dir
+
+Link the directory './micros/my-micro-dir' to the existing micro 'my-micro' from project 'my-project'.
+
+3. deta link --name my-micro-staging --env-profile staging
+
+Link the existing micro 'my-micro-staging' to the env profile 'staging' of the current directory.
+Select the micro with --env-profile on other commands, e.g. ` + "`deta deploy --env-profile staging`" + `.`
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// max number of schedules fetched concurrently when listing micros
+	maxScheduleRequests = 8
+)
+
+var (
+	listOutput string
+
+	listCmd = &cobra.Command{
+		Use:     "list [flags]",
+		Short:   "List deta micros in a project",
+		RunE:    list,
+		Example: listExamples(),
+		Args:    cobra.NoArgs,
+	}
+)
+
+func init() {
+	listCmd.Flags().StringVar(&projectName, "project", "", "project to list the micros of")
+	listCmd.Flags().StringVar(&spaceFlag, "space", "", spaceUsage)
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", textOutput, "output format, 'text' or 'json'")
+	rootCmd.AddCommand(listCmd)
+}
+
+func list(cmd *cobra.Command, args []string) error {
+	if listOutput != textOutput && listOutput != jsonOutput {
+		return fmt.Errorf("unsupported output format '%s', see `deta list --help`", listOutput)
+	}
+
+	runtimeManager, err := runtime.NewManager(nil, false)
+	if err != nil {
+		return err
+	}
+
+	u, err := getUserInfo(runtimeManager, client)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		projectName = u.DefaultProject
+	}
+
+	outputs, err := getMicrosOutput(projectName, u.DefaultSpace)
+	if err != nil {
+		return err
+	}
+
+	if listOutput == jsonOutput {
+		output, err := prettyPrint(outputs)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+		return nil
+	}
+
+	if len(outputs) == 0 {
+		fmt.Printf("No micros in project '%s'\n", projectName)
+		return nil
+	}
+	fmt.Print(formatMicrosTable(outputs))
+	return nil
+}
+
+// getMicrosOutput gets the details of the micros in a project sorted by name
+func getMicrosOutput(project string, space int64) ([]*progDetailsOutput, error) {
+	res, err := client.GetPrograms(&api.GetProgramsRequest{
+		Project: project,
+		Space:   space,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// schedules are fetched concurrently with at most maxScheduleRequests requests at the same time
+	outputs := make([]*progDetailsOutput, len(res.Programs))
+	errs := make([]error, len(res.Programs))
+	sem := make(chan struct{}, maxScheduleRequests)
+	var wg sync.WaitGroup
+	for i, p := range res.Programs {
+		if p.ScheduleID == 0 {
+			outputs[i] = newProgDetailsOutput(progInfoFromDetails(p, ""))
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p *api.GetProgDetailsResponse) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			schedule, err := client.GetSchedule(&api.GetScheduleRequest{
+				ProgramID: p.ID,
+			})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			var cronExpression string
+			if schedule != nil {
+				cronExpression = schedule.Expression
+			}
+			outputs[i] = newProgDetailsOutput(progInfoFromDetails(p, cronExpression))
+		}(i, p)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Name < outputs[j].Name
+	})
+	return outputs, nil
+}
+
+// formatMicrosTable formats the details of micros as a table with a row for each micro
+func formatMicrosTable(outputs []*progDetailsOutput) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tRUNTIME\tENDPOINT\tVISOR\tHTTP AUTH\tCRON")
+	for _, o := range outputs {
+		cron := o.Cron
+		if cron == "" {
+			cron = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", o.Name, o.Runtime, o.Endpoint, o.Visor, o.Auth, cron)
+	}
+	w.Flush()
+	return b.String()
+}
+
+func listExamples() string {
+	return `
+1. deta list
+
+List the micros in the 'default' project.
+
+2. deta list --project my-project --output json
+
+List the micros in the project 'my-project' in json format.`
+}
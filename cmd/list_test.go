@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFormatMicrosTable(t *testing.T) {
+	outputs := []*progDetailsOutput{
+		{
+			Name:     "api",
+			Runtime:  "python3.9",
+			Endpoint: "https://abc.deta.dev",
+			Visor:    "enabled",
+			Auth:     "disabled",
+			Cron:     "5 minutes",
+		},
+		{
+			Name:     "frontend-app",
+			Runtime:  "nodejs14.x",
+			Endpoint: "https://defgh.deta.dev",
+			Visor:    "disabled",
+			Auth:     "enabled",
+		},
+	}
+	expected := "" +
+		"NAME          RUNTIME     ENDPOINT                VISOR     HTTP AUTH  CRON\n" +
+		"api           python3.9   https://abc.deta.dev    enabled   disabled   5 minutes\n" +
+		"frontend-app  nodejs14.x  https://defgh.deta.dev  disabled  enabled    -\n"
+	assert.Equal(t, expected, formatMicrosTable(outputs))
+}
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,36 +20,100 @@ import (
 const (
 	SPACE                    = " "
 	LogPollDurationInSeconds = 1
+
+	// default time window of the logs before --until or now
+	defaultLogsWindow = 30 * time.Minute
+
+	// output format with a json object per line
+	ndjsonOutput = "ndjson"
 )
 
 var (
 	followFlag bool
+	logsSince  string
+	logsUntil  string
+	logsGrep   string
+	logsLevel  string
+	logsLimit  int
+	logsOutput string
+
+	// ranks of levels of json log lines, lowercase
+	logLevelRanks = map[string]int{
+		"trace":    0,
+		"debug":    1,
+		"info":     2,
+		"warn":     3,
+		"warning":  3,
+		"error":    4,
+		"fatal":    5,
+		"critical": 5,
+		"panic":    5,
+	}
+
+	// keys of the level in json log lines
+	logLevelKeys = []string{"level", "levelname", "severity", "lvl"}
 
 	logsCmd = &cobra.Command{
 		Use:   "logs [flags]",
 		Short: "Get logs from a micro",
-		Long: `Get logs from a visor disabled micro of the last 30 mins. 
+		Long: `Get logs from a visor disabled micro, by default of the last 30 mins.
 Use command with the --follow flag to follow logs.
-Using --follow automatically disables visor and renables it when the command exits.`,
-		Args: cobra.NoArgs,
-		RunE: logs,
+Using --follow automatically disables visor and renables it when the command exits.
+With --follow only new logs are shown, --since, --until and --limit are ignored.`,
+		Args:    cobra.NoArgs,
+		Example: logsExamples(),
+		RunE:    logs,
 	}
 )
 
+// logsQuery time window and filters of logs
+type logsQuery struct {
+	start time.Time
+	end   time.Time // zero to get logs until now
+	grep  *regexp.Regexp
+	level int // minimum rank of the level of json log lines, -1 for all logs
+	limit int // 0 for no limit
+}
+
+// logOutput log in json output
+type logOutput struct {
+	Timestamp string `json:"timestamp"`
+	Log       string `json:"log"`
+	Level     string `json:"level,omitempty"`
+}
+
 func init() {
 	logsCmd.Flags().BoolVarP(&followFlag, "follow", "f", false, "follow logs")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "get logs after a duration ago, e.g. '2h' or '3d', or a RFC3339 time, defaults to 30m before --until")
+	logsCmd.Flags().StringVar(&logsUntil, "until", "", "get logs before a duration ago or a RFC3339 time, defaults to now")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "only show logs matching a regular expression")
+	logsCmd.Flags().StringVar(&logsLevel, "level", "", "only show json logs with at least this level, e.g. 'warning'")
+	logsCmd.Flags().IntVar(&logsLimit, "limit", 0, "maximum number of logs to show, 0 for no limit")
+	logsCmd.Flags().StringVarP(&logsOutput, "output", "o", textOutput, "output format, 'text', 'json' or 'ndjson'")
+	logsCmd.Flags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
 	rootCmd.AddCommand(logsCmd)
 }
 
 func logs(cmd *cobra.Command, args []string) error {
+	if logsOutput != textOutput && logsOutput != jsonOutput && logsOutput != ndjsonOutput {
+		return fmt.Errorf("unsupported output format '%s', see `deta logs --help`", logsOutput)
+	}
+	if followFlag && logsOutput == jsonOutput {
+		return fmt.Errorf("output format 'json' is not supported with --follow, use 'ndjson'")
+	}
+	query, err := newLogsQuery(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
 	wd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	runtimeManager, err := newProfileManager(&wd, false)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	isInitialized, err := runtimeManager.IsInitialized()
@@ -53,7 +122,7 @@ func logs(cmd *cobra.Command, args []string) error {
 	}
 
 	if !isInitialized {
-		return fmt.Errorf("no deta micro initialized in '%s'", wd)
+		return notInitializedError(wd)
 	}
 
 	progInfo, err := runtimeManager.GetProgInfo()
@@ -67,37 +136,157 @@ func logs(cmd *cobra.Command, args []string) error {
 
 	// no follow flag simply print the logs
 	if !followFlag {
-		logs, err := getLogs(progInfo.ID)
+		logs, err := getLogs(progInfo.ID, query)
 		if err != nil {
 			return err
 		}
 
+		if logsOutput == jsonOutput {
+			outputs := make([]*logOutput, 0, len(logs))
+			for _, log := range logs {
+				outputs = append(outputs, newLogOutput(log))
+			}
+			output, err := prettyPrint(outputs)
+			if err != nil {
+				return err
+			}
+			fmt.Println(output)
+			return nil
+		}
+
 		for _, log := range logs {
-			printLogs(log.Timestamp, log.Log)
+			if err := printLog(log); err != nil {
+				return err
+			}
 		}
 
 		return nil
 	}
 
 	// follow flag specified
-	return followLogs(progInfo)
+	return followLogs(progInfo, query)
+}
+
+// newLogsQuery gets the query of the logs from the flags
+func newLogsQuery(now time.Time) (*logsQuery, error) {
+	q := &logsQuery{
+		level: -1,
+		limit: logsLimit,
+	}
+
+	var err error
+	end := now
+	if logsUntil != "" {
+		q.end, err = parseLogsTime(logsUntil, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --until '%s', use a duration like '2h' or '3d' or a RFC3339 time", logsUntil)
+		}
+		end = q.end
+	}
+	if logsSince == "" {
+		q.start = end.Add(-defaultLogsWindow)
+	} else {
+		q.start, err = parseLogsTime(logsSince, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since '%s', use a duration like '2h' or '3d' or a RFC3339 time", logsSince)
+		}
+	}
+	if !end.After(q.start) {
+		return nil, fmt.Errorf("--until must be after --since")
+	}
+
+	if logsGrep != "" {
+		q.grep, err = regexp.Compile(logsGrep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --grep '%s': %v", logsGrep, err)
+		}
+	}
+
+	if logsLevel != "" {
+		rank, ok := logLevelRanks[strings.ToLower(logsLevel)]
+		if !ok {
+			return nil, fmt.Errorf("unknown --level '%s', use 'debug', 'info', 'warning', 'error' or 'critical'", logsLevel)
+		}
+		q.level = rank
+	}
+
+	if q.limit < 0 {
+		return nil, fmt.Errorf("--limit must not be negative")
+	}
+	return q, nil
+}
+
+// parseLogsTime parses a RFC3339 time or a duration before now
+// durations are go durations like '90m' or '2h30m' or a number of days like '3d'
+func parseLogsTime(value string, now time.Time) (time.Time, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// logLevel gets the level of a json log line, empty if the line has no level
+func logLevel(line string) string {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return ""
+	}
+	for _, k := range logLevelKeys {
+		if level, ok := fields[k].(string); ok {
+			return strings.ToLower(level)
+		}
+	}
+	return ""
+}
+
+// matches checks if a log line is matched by the filters of the query
+func (q *logsQuery) matches(line string) bool {
+	if q.grep != nil && !q.grep.MatchString(line) {
+		return false
+	}
+	if q.level >= 0 {
+		rank, ok := logLevelRanks[logLevel(line)]
+		if !ok || rank < q.level {
+			return false
+		}
+	}
+	return true
 }
 
-func getLogs(progID string) ([]api.LogType, error) {
+// getLogs gets the logs of the query, paginating until the end or the limit of the query
+func getLogs(progID string, q *logsQuery) ([]api.LogType, error) {
+	end := q.end
+	if end.IsZero() {
+		end = time.Now().UTC()
+	}
+
 	lastToken := ""
 	logs := make([]api.LogType, 0)
-	current := time.Now().UTC()
 	for {
 		res, err := client.GetLogs(&api.GetLogsRequest{
 			ProgramID: progID,
-			Start:     current.Add(-30*time.Minute).UnixNano() / int64(time.Millisecond),
-			End:       current.UnixNano() / int64(time.Millisecond),
+			Start:     q.start.UnixNano() / int64(time.Millisecond),
+			End:       end.UnixNano() / int64(time.Millisecond),
 			LastToken: lastToken,
 		})
 		if err != nil {
 			return nil, err
 		}
-		logs = append(logs, res.Logs...)
+		for _, log := range res.Logs {
+			if !q.matches(log.Log) {
+				continue
+			}
+			logs = append(logs, log)
+			if q.limit > 0 && len(logs) == q.limit {
+				return logs, nil
+			}
+		}
 
 		if len(res.LastToken) == 0 {
 			break
@@ -107,27 +296,54 @@ func getLogs(progID string) ([]api.LogType, error) {
 	return logs, nil
 }
 
-// show new logs only shows logs after start and not in seenLogs
-func showNewLogs(progID string, start int64, seenLogs map[int64]struct{}) error {
-	logs, err := getLogs(progID)
+// logsCursor position of the logs shown while following logs
+type logsCursor struct {
+	timestamp int64               // timestamp of the last shown logs
+	seen      map[string]struct{} // shown logs with the timestamp
+}
+
+// show new logs only shows logs after the cursor and moves the cursor to the last shown log
+// only logs from the timestamp of the cursor are fetched
+func showNewLogs(progID string, q *logsQuery, c *logsCursor) error {
+	logs, err := getLogs(progID, &logsQuery{
+		start: time.Unix(0, c.timestamp*int64(time.Millisecond)),
+		grep:  q.grep,
+		level: q.level,
+	})
 	if err != nil {
 		return err
 	}
 
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp < logs[j].Timestamp
+	})
 	for _, log := range logs {
-		_, ok := seenLogs[log.Timestamp]
-		if !ok && log.Timestamp > start {
-			printLogs(log.Timestamp, log.Log)
-			seenLogs[log.Timestamp] = struct{}{}
+		if log.Timestamp < c.timestamp {
+			continue
+		}
+		if _, ok := c.seen[log.Log]; ok && log.Timestamp == c.timestamp {
+			continue
+		}
+		if err := printLog(log); err != nil {
+			return err
+		}
+		if log.Timestamp > c.timestamp {
+			c.timestamp = log.Timestamp
+			c.seen = make(map[string]struct{})
 		}
+		c.seen[log.Log] = struct{}{}
 	}
 	return nil
 }
 
 // follow logs polls for new logs
 // waits on a poll ticker or a signal
-func followLogs(progInfo *runtime.ProgInfo) error {
-	start := time.Now().UTC().UnixNano() / int64(time.Millisecond)
+// only logs of the query after the command started are shown
+func followLogs(progInfo *runtime.ProgInfo, q *logsQuery) error {
+	cursor := &logsCursor{
+		timestamp: time.Now().UTC().UnixNano() / int64(time.Millisecond),
+		seen:      make(map[string]struct{}),
+	}
 
 	// signals channel
 	sigs := make(chan os.Signal, 1)
@@ -140,7 +356,9 @@ func followLogs(progInfo *runtime.ProgInfo) error {
 		syscall.SIGQUIT,
 	)
 
-	fmt.Println("Listening for logs...")
+	if logsOutput == textOutput {
+		fmt.Println("Listening for logs...")
+	}
 	// disable visor mode temporarily if it's on
 	enableVisor := false
 	if progInfo.Visor == "debug" {
@@ -158,8 +376,6 @@ func followLogs(progInfo *runtime.ProgInfo) error {
 	ticker := time.NewTicker(LogPollDurationInSeconds * time.Second)
 	defer ticker.Stop()
 
-	// track seen logs
-	seenLogs := make(map[int64]struct{})
 	for {
 		select {
 		case <-sigs:
@@ -177,14 +393,60 @@ func followLogs(progInfo *runtime.ProgInfo) error {
 			}
 			return nil
 		case <-ticker.C:
-			if err := showNewLogs(progInfo.ID, start, seenLogs); err != nil {
+			if err := showNewLogs(progInfo.ID, q, cursor); err != nil {
 				return err
 			}
 		}
 	}
 }
 
+// newLogOutput gets the json output of a log
+func newLogOutput(log api.LogType) *logOutput {
+	return &logOutput{
+		Timestamp: formatLogTimestamp(log.Timestamp),
+		Log:       log.Log,
+		Level:     logLevel(log.Log),
+	}
+}
+
+// printLog prints a log in the text or ndjson output format
+func printLog(log api.LogType) error {
+	if logsOutput == ndjsonOutput {
+		b, err := json.Marshal(newLogOutput(log))
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	printLogs(log.Timestamp, log.Log)
+	return nil
+}
+
+func formatLogTimestamp(timestamp int64) string {
+	return time.Time(time.Unix(0, timestamp*int64(time.Millisecond))).Format(time.RFC3339)
+}
+
 func printLogs(timestamp int64, message string) {
-	strDateTime := time.Time(time.Unix(0, timestamp*int64(time.Millisecond))).Format(time.RFC3339)
-	fmt.Printf("[%s] %s\n", strDateTime, message)
+	fmt.Printf("[%s] %s\n", formatLogTimestamp(timestamp), message)
+}
+
+func logsExamples() string {
+	return `
+1. deta logs --since 2h --grep "timeout|refused"
+
+Show the logs of the last 2 hours matching the regular expression 'timeout|refused'.
+
+2. deta logs --since 2021-03-01T10:00:00Z --until 2021-03-01T12:00:00Z --limit 100
+
+Show the first 100 logs between 10:00 and 12:00 (UTC) on the 1st of March 2021.
+
+3. deta logs --since 3d --level error --output ndjson
+
+Show the json logs of the last 3 days with a level of at least 'error', one json object per line.
+The level is read from the 'level', 'levelname', 'severity' or 'lvl' field of json log lines.
+
+4. deta logs --follow --level warning
+
+Follow the json logs with a level of at least 'warning'.`
 }
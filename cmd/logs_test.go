@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseLogsTime(t *testing.T) {
+	now := time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Time
+		isErr    bool
+	}{
+		{"30m", time.Date(2021, time.March, 10, 11, 30, 0, 0, time.UTC), false},
+		{"2h30m", time.Date(2021, time.March, 10, 9, 30, 0, 0, time.UTC), false},
+		{"3d", time.Date(2021, time.March, 7, 12, 0, 0, 0, time.UTC), false},
+		{"2021-03-01T10:00:00Z", time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC), false},
+		{"-2h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"2021-03-01", time.Time{}, true},
+	}
+
+	for _, tc := range testCases {
+		tm, err := parseLogsTime(tc.value, now)
+		if tc.isErr {
+			assert.Assert(t, err != nil, tc.value)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Assert(t, tm.Equal(tc.expected), "%s: got %s", tc.value, tm)
+	}
+}
+
+func TestLogsQueryMatches(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   *logsQuery
+		line    string
+		matches bool
+	}{
+		{"no filters", &logsQuery{level: -1}, "hello", true},
+		{"grep match", &logsQuery{level: -1, grep: regexp.MustCompile("time(out)?")}, "request timeout", true},
+		{"grep no match", &logsQuery{level: -1, grep: regexp.MustCompile("^error")}, "no error", false},
+		{"level above", &logsQuery{level: logLevelRanks["warning"]}, `{"level": "ERROR", "msg": "failed"}`, true},
+		{"level equal", &logsQuery{level: logLevelRanks["warning"]}, `{"levelname": "warn"}`, true},
+		{"level below", &logsQuery{level: logLevelRanks["warning"]}, `{"severity": "info"}`, false},
+		{"unknown level", &logsQuery{level: logLevelRanks["debug"]}, `{"level": "verbose"}`, false},
+		{"no json", &logsQuery{level: logLevelRanks["debug"]}, "error: failed", false},
+		{"grep and level", &logsQuery{level: logLevelRanks["error"], grep: regexp.MustCompile("db")}, `{"level": "error", "msg": "api"}`, false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.query.matches(tc.line), tc.matches, tc.name)
+	}
+}
+
+func TestNewLogsQuery(t *testing.T) {
+	defer func() {
+		logsSince, logsUntil = "", ""
+	}()
+	now := time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		since string
+		until string
+		start time.Time
+		end   time.Time
+		isErr bool
+	}{
+		{"", "", now.Add(-30 * time.Minute), time.Time{}, false},
+		{"2h", "", now.Add(-2 * time.Hour), time.Time{}, false},
+		{"", "2021-03-01T12:00:00Z", time.Date(2021, time.March, 1, 11, 30, 0, 0, time.UTC), time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC), false},
+		{"3d", "1d", now.AddDate(0, 0, -3), now.AddDate(0, 0, -1), false},
+		{"1h", "2h", time.Time{}, time.Time{}, true},
+	}
+
+	for _, tc := range testCases {
+		logsSince, logsUntil = tc.since, tc.until
+		q, err := newLogsQuery(now)
+		if tc.isErr {
+			assert.Assert(t, err != nil, "since: %s, until: %s", tc.since, tc.until)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Assert(t, q.start.Equal(tc.start), "since: %s, until: %s, got start %s", tc.since, tc.until, q.start)
+		assert.Assert(t, q.end.Equal(tc.end), "since: %s, until: %s, got end %s", tc.since, tc.until, q.end)
+	}
+}
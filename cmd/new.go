@@ -35,9 +35,11 @@ func init() {
 	newCmd.Flags().BoolVarP(&nodeFlag, "node", "n", false, "create a micro with node runtime")
 	newCmd.Flags().BoolVarP(&pythonFlag, "python", "p", false, "create a micro with python runtime")
 	newCmd.Flags().StringVar(&progName, "name", "", "deta micro name")
+	newCmd.Flags().StringVar(&spaceFlag, "space", "", spaceUsage)
 	newCmd.Flags().StringVar(&projectName, "project", "", "project to create the micro under")
 	newCmd.Flags().StringVar(&runtimeName, "runtime", "", "runtime version\n\tPython: python3.7, python3.8, python3.9\n\tNode: nodejs12, nodejs14")
 
+	newCmd.Flags().StringVar(&envProfile, "env-profile", "", "create the micro for an env profile, linking the directory to another micro")
 	rootCmd.AddCommand(newCmd)
 }
 
@@ -61,7 +63,7 @@ func new(cmd *cobra.Command, args []string) error {
 		wd = args[0]
 	}
 
-	runtimeManager, err := runtime.NewManager(&wd, true)
+	runtimeManager, err := newProfileManager(&wd, true)
 	if err != nil {
 		return err
 	}
@@ -85,7 +87,7 @@ func new(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return err
 			}
-			runtimeManager, err = runtime.NewManager(&wd, true)
+			runtimeManager, err = newProfileManager(&wd, true)
 			if err != nil {
 				return err
 			}
@@ -96,6 +98,9 @@ func new(cmd *cobra.Command, args []string) error {
 		// use current working dir as the default name of the program
 		// replace spaces with underscore from the dir name if present
 		progName = strings.ReplaceAll(filepath.Base(wd), " ", "_")
+		if envProfile != "" {
+			progName = fmt.Sprintf("%s-%s", progName, envProfile)
+		}
 	}
 
 	// checks if a program is already present in the working directory
@@ -227,24 +232,17 @@ func new(cmd *cobra.Command, args []string) error {
 	}
 
 	if c != nil {
-		_, err = client.Deploy(&api.DeployRequest{
-			ProgramID:   res.ID,
-			Changes:     c.Changes,
-			BinaryFiles: c.BinaryFiles,
-			Deletions:   c.Deletions,
-			Account:     res.Account,
-			Region:      res.Region,
-		})
+		err = uploadChanges(runtimeManager, newProgInfo, c, os.Stdout)
 		if err != nil {
 			return err
 		}
+		storeSnapshot(runtimeManager, os.Stdout)
 	}
 
 	dc, err := runtimeManager.GetDepChanges()
 	if err != nil {
 		return err
 	}
-	runtimeManager.StoreState()
 
 	if dc != nil {
 		fmt.Println("Adding dependencies...")
@@ -305,5 +303,16 @@ Create a new deta micro with the python runtime, name 'my-github-webhook' and in
 4. deta new --runtime nodejs12 --name my-node-micro
 
 Create a new deta micro with the node (nodejs12.x) runtime in the directory './my-node-micro'.
-'./my-node-micro' must not contain a python entrypoint file ('main.py') if directory is already present. `
+'./my-node-micro' must not contain a python entrypoint file ('main.py') if directory is already present. 
+
+5. deta new --env-profile staging
+
+Create a new deta micro for the env profile 'staging' from the current directory, named after the directory with the suffix '-staging'.
+The directory can be linked to a micro for each env profile, select the profile with --env-profile on other commands:
+
+  deta deploy --env-profile staging
+  deta update --env-profile staging
+  deta details --env-profile staging
+
+Each env profile has its own micro, deployment state and env file '.deta/envs/<profile>/.env'.`
 }
@@ -11,13 +11,14 @@ import (
 var (
 	projectsCmd = &cobra.Command{
 		Use:   "projects",
-		Short: "List deta projects",
+		Short: "List and manage deta projects",
 		RunE:  listProjects,
 		Args:  cobra.NoArgs,
 	}
 )
 
 func init() {
+	projectsCmd.PersistentFlags().StringVar(&spaceFlag, "space", "", spaceUsage)
 	rootCmd.AddCommand(projectsCmd)
 }
 
@@ -51,3 +52,19 @@ func listProjects(cmd *cobra.Command, args []string) error {
 	fmt.Println(output)
 	return nil
 }
+
+// findProject finds a project in the space by name or id
+func findProject(space int64, project string) (*api.GetProjectsItem, error) {
+	res, err := client.GetProjects(&api.GetProjectsRequest{
+		SpaceID: space,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range res.Projects {
+		if p.Name == project || p.ID == project {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("project '%s' not found, see `deta projects`", project)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	projectDesc string
+
+	projectsCreateCmd = &cobra.Command{
+		Use:     "create [flags] <name>",
+		Short:   "Create a deta project",
+		Args:    cobra.ExactArgs(1),
+		Example: projectsCreateExamples(),
+		RunE:    createProject,
+	}
+)
+
+func init() {
+	projectsCreateCmd.Flags().StringVarP(&projectDesc, "description", "d", "", "project description")
+	projectsCmd.AddCommand(projectsCreateCmd)
+}
+
+func createProject(cmd *cobra.Command, args []string) error {
+	runtimeManager, err := runtime.NewManager(nil, false)
+	if err != nil {
+		return err
+	}
+
+	u, err := getUserInfo(runtimeManager, client)
+	if err != nil {
+		return err
+	}
+
+	project, err := client.CreateProject(&api.CreateProjectRequest{
+		SpaceID:     u.DefaultSpace,
+		Name:        args[0],
+		Description: projectDesc,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Successfully created project '%s'\n", project.Name)
+	return nil
+}
+
+func projectsCreateExamples() string {
+	return `
+1. deta projects create my-project
+
+Create a new project 'my-project'.
+
+2. deta projects create my-project --description "micros of my project"
+
+Create a new project 'my-project' with a description.
+
+Create micros in the project with ` + "`deta new --project my-project`" + `.`
+}
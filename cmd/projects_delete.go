@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteProjectConfirm string
+
+	projectsDeleteCmd = &cobra.Command{
+		Use:     "delete [flags] <project>",
+		Short:   "Delete a deta project",
+		Args:    cobra.ExactArgs(1),
+		Example: projectsDeleteExamples(),
+		RunE:    deleteProject,
+	}
+)
+
+func init() {
+	projectsDeleteCmd.Flags().StringVar(&deleteProjectConfirm, "confirm", "", "name of the project to confirm the deletion without asking")
+	projectsCmd.AddCommand(projectsDeleteCmd)
+}
+
+func deleteProject(cmd *cobra.Command, args []string) error {
+	runtimeManager, err := runtime.NewManager(nil, false)
+	if err != nil {
+		return err
+	}
+
+	u, err := getUserInfo(runtimeManager, client)
+	if err != nil {
+		return err
+	}
+
+	project, err := findProject(u.DefaultSpace, args[0])
+	if err != nil {
+		return err
+	}
+	if project.Name == u.DefaultProject {
+		return fmt.Errorf("can not delete the default project '%s'", project.Name)
+	}
+
+	// micros are not deleted with the project
+	res, err := client.GetPrograms(&api.GetProgramsRequest{
+		Project: project.Name,
+		Space:   u.DefaultSpace,
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Programs) > 0 {
+		return fmt.Errorf("project '%s' has %d micros, delete them first with `deta destroy`", project.Name, len(res.Programs))
+	}
+
+	confirmed, err := confirmName(project.Name, deleteProjectConfirm)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Name does not match, delete aborted")
+		return nil
+	}
+
+	err = client.DeleteProject(&api.DeleteProjectRequest{
+		SpaceID:   u.DefaultSpace,
+		ProjectID: project.ID,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Successfully deleted project '%s'\n", project.Name)
+	return nil
+}
+
+func projectsDeleteExamples() string {
+	return `
+1. deta projects delete my-project
+
+Delete the project 'my-project' after typing its name to confirm.
+Only projects without micros can be deleted.
+
+2. deta projects delete my-project --confirm my-project
+
+Delete the project 'my-project' without asking for confirmation.`
+}
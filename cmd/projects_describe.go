@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	describeOutput string
+
+	projectsDescribeCmd = &cobra.Command{
+		Use:     "describe [flags] [project]",
+		Short:   "Show a deta project and its micros",
+		Args:    cobra.MaximumNArgs(1),
+		Example: projectsDescribeExamples(),
+		RunE:    describeProject,
+	}
+)
+
+type projectOutput struct {
+	Name        string               `json:"name"`
+	ID          string               `json:"id"`
+	Description string               `json:"description,omitempty"`
+	Created     string               `json:"created"`
+	Micros      []*progDetailsOutput `json:"micros"`
+}
+
+func init() {
+	projectsDescribeCmd.Flags().StringVarP(&describeOutput, "output", "o", textOutput, "output format, 'text' or 'json'")
+	projectsCmd.AddCommand(projectsDescribeCmd)
+}
+
+func describeProject(cmd *cobra.Command, args []string) error {
+	if describeOutput != textOutput && describeOutput != jsonOutput {
+		return fmt.Errorf("unsupported output format '%s', see `deta projects describe --help`", describeOutput)
+	}
+
+	runtimeManager, err := runtime.NewManager(nil, false)
+	if err != nil {
+		return err
+	}
+
+	u, err := getUserInfo(runtimeManager, client)
+	if err != nil {
+		return err
+	}
+
+	name := u.DefaultProject
+	if len(args) != 0 {
+		name = args[0]
+	}
+	project, err := findProject(u.DefaultSpace, name)
+	if err != nil {
+		return err
+	}
+
+	micros, err := getMicrosOutput(project.Name, u.DefaultSpace)
+	if err != nil {
+		return err
+	}
+
+	if describeOutput == jsonOutput {
+		output, err := prettyPrint(newProjectOutput(project, micros))
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+		return nil
+	}
+	fmt.Print(formatProject(newProjectOutput(project, micros)))
+	return nil
+}
+
+func newProjectOutput(p *api.GetProjectsItem, micros []*progDetailsOutput) *projectOutput {
+	return &projectOutput{
+		Name:        p.Name,
+		ID:          p.ID,
+		Description: p.Description,
+		Created:     p.Created,
+		Micros:      micros,
+	}
+}
+
+// formatProject formats a project with a table of its micros
+func formatProject(o *projectOutput) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", o.Name)
+	fmt.Fprintf(w, "ID:\t%s\n", o.ID)
+	if o.Description != "" {
+		fmt.Fprintf(w, "Description:\t%s\n", o.Description)
+	}
+	fmt.Fprintf(w, "Created:\t%s\n", o.Created)
+	w.Flush()
+
+	b.WriteString("\n")
+	if len(o.Micros) == 0 {
+		b.WriteString("No micros in the project\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Micros (%d):\n", len(o.Micros))
+	b.WriteString(formatMicrosTable(o.Micros))
+	return b.String()
+}
+
+func projectsDescribeExamples() string {
+	return `
+1. deta projects describe
+
+Show the 'default' project and its micros.
+
+2. deta projects describe my-project --output json
+
+Show the project 'my-project' and its micros in json format.`
+}
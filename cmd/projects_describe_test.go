@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFormatProject(t *testing.T) {
+	o := &projectOutput{
+		Name:        "my-project",
+		ID:          "a1b2c3",
+		Description: "micros of my project",
+		Created:     "2021-01-02T10:00:00Z",
+	}
+	expected := "" +
+		"Name:        my-project\n" +
+		"ID:          a1b2c3\n" +
+		"Description: micros of my project\n" +
+		"Created:     2021-01-02T10:00:00Z\n" +
+		"\n" +
+		"No micros in the project\n"
+	assert.Equal(t, expected, formatProject(o))
+
+	o.Description = ""
+	o.Micros = []*progDetailsOutput{
+		{
+			Name:     "api",
+			Runtime:  "python3.9",
+			Endpoint: "https://abc.deta.dev",
+			Visor:    "enabled",
+			Auth:     "disabled",
+		},
+	}
+	expected = "" +
+		"Name:    my-project\n" +
+		"ID:      a1b2c3\n" +
+		"Created: 2021-01-02T10:00:00Z\n" +
+		"\n" +
+		"Micros (1):\n" +
+		"NAME  RUNTIME    ENDPOINT              VISOR    HTTP AUTH  CRON\n" +
+		"api   python3.9  https://abc.deta.dev  enabled  disabled   -\n"
+	assert.Equal(t, expected, formatProject(o))
+}
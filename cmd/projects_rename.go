@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	projectsRenameCmd = &cobra.Command{
+		Use:     "rename <project> <new-name>",
+		Short:   "Rename a deta project",
+		Args:    cobra.ExactArgs(2),
+		Example: projectsRenameExamples(),
+		RunE:    renameProject,
+	}
+)
+
+func init() {
+	projectsCmd.AddCommand(projectsRenameCmd)
+}
+
+func renameProject(cmd *cobra.Command, args []string) error {
+	runtimeManager, err := runtime.NewManager(nil, false)
+	if err != nil {
+		return err
+	}
+
+	u, err := getUserInfo(runtimeManager, client)
+	if err != nil {
+		return err
+	}
+
+	project, err := findProject(u.DefaultSpace, args[0])
+	if err != nil {
+		return err
+	}
+
+	err = client.UpdateProjectName(&api.UpdateProjectNameRequest{
+		SpaceID:   u.DefaultSpace,
+		ProjectID: project.ID,
+		Name:      args[1],
+	})
+	if err != nil {
+		return err
+	}
+
+	// the default project is cached with the user info of the default space
+	cached, err := runtimeManager.GetUserInfo()
+	if err != nil {
+		return err
+	}
+	if cached != nil && cached.DefaultSpace == u.DefaultSpace && cached.DefaultProject == project.Name {
+		cached.DefaultProject = args[1]
+		err = runtimeManager.StoreUserInfo(cached)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Successfully renamed project '%s' to '%s'\n", project.Name, args[1])
+	return nil
+}
+
+func projectsRenameExamples() string {
+	return `
+1. deta projects rename my-project my-renamed-project
+
+Rename the project 'my-project' to 'my-renamed-project'.`
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -10,7 +11,8 @@ import (
 )
 
 var (
-	forcePull bool
+	forcePull      bool
+	failOnConflict bool
 
 	pullCmd = &cobra.Command{
 		Use:     "pull [flags]",
@@ -23,11 +25,13 @@ var (
 
 func init() {
 	pullCmd.Flags().BoolVarP(&forcePull, "force", "f", false, "force overwrite of existing files")
+	pullCmd.Flags().BoolVar(&failOnConflict, "fail-on-conflict", false, "do not change any files if files were changed both locally and in the deployed code")
+	pullCmd.Flags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
 	rootCmd.AddCommand(pullCmd)
 }
 
 func pull(cmd *cobra.Command, args []string) error {
-	runtimeManager, err := runtime.NewManager(nil, false)
+	runtimeManager, err := newProfileManager(nil, false)
 	if err != nil {
 		return err
 	}
@@ -40,17 +44,6 @@ func pull(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no deta micro initialized in current directory")
 	}
 
-	if !forcePull {
-		fmt.Println("Files already present may be overwritten. Continue? [y/n]")
-		var cont string
-		fmt.Scanf("%s", &cont)
-		if strings.ToLower(cont) != "y" {
-			fmt.Println("Pull aborted")
-			return nil
-		}
-
-	}
-
 	progInfo, err := runtimeManager.GetProgInfo()
 	if err != nil {
 		return err
@@ -67,7 +60,32 @@ func pull(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	err = runtimeManager.WriteProgramFiles(o.ZipFile, nil, true, progInfo.Runtime)
+	if forcePull {
+		err = runtimeManager.WriteProgramFiles(o.ZipFile, nil, true, progInfo.Runtime)
+		if err != nil {
+			return err
+		}
+	}
+
+	// files are already the same as the deployed code after a force pull
+	// merging only updates the state
+	// nothing is written if there are conflicts until confirmed
+	result, err := runtimeManager.Pull(o.ZipFile, false)
+	if errors.Is(err, runtime.ErrMergeConflicts) {
+		printPullConflicts("Conflicts:", result)
+		fmt.Println()
+		if failOnConflict {
+			return fmt.Errorf("pull aborted, %d files changed both locally and in the deployed code", len(result.Conflicts))
+		}
+		fmt.Println("Conflicting lines will be written between conflict markers. Continue? [y/n]")
+		var cont string
+		fmt.Scanf("%s", &cont)
+		if strings.ToLower(cont) != "y" {
+			fmt.Println("Pull aborted")
+			return nil
+		}
+		result, err = runtimeManager.Pull(o.ZipFile, true)
+	}
 	if err != nil {
 		return err
 	}
@@ -75,18 +93,58 @@ func pull(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		fmt.Println(err)
 	}
+
+	printPullFiles("Updated files:", "~", result.Updated)
+	printPullFiles("Deleted files:", "-", result.Deleted)
+	printPullFiles("Merged files:", "+", result.Merged)
+	printPullFiles("Files of include dirs not changed, overwritten in the deployed code on the next deploy:", "*", result.Included)
+	if len(result.Conflicts) > 0 {
+		printPullConflicts("Conflicts, resolve before deploying:", result)
+		fmt.Println()
+		fmt.Println("Pulled latest deployed code with conflicts")
+		return nil
+	}
 	fmt.Println("Successfully pulled latest deployed code")
 	return nil
 }
 
+func printPullFiles(title, symbol string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Println(title)
+	for _, f := range files {
+		fmt.Printf("  %s %s\n", symbol, f)
+	}
+}
+
+// printPullConflicts prints the conflicts of a pull with the reason if not written with conflict markers
+func printPullConflicts(title string, result *runtime.PullResult) {
+	fmt.Println(title)
+	for _, f := range result.Conflicts {
+		if reason, ok := result.Reasons[f]; ok {
+			fmt.Printf("  ! %s (%s)\n", f, reason)
+			continue
+		}
+		fmt.Printf("  ! %s\n", f)
+	}
+}
+
 func pullExamples() string {
 	return `
 1. deta pull
 
-Pull latest changes of deta micro present in the current directory. 
-Asks for approval before overwriting the files in the current directory.
+Pull latest changes of deta micro present in the current directory.
+Changes made only in the deployed code are applied, local changes are kept.
+Files changed both locally and in the deployed code are merged,
+conflicting lines are written between conflict markers after confirming.
+
+2. deta pull --fail-on-conflict
+
+Pull latest changes of deta micro present in the current directory.
+Does not change any files if files were changed both locally and in the deployed code.
 
-2. deta pull --force
+3. deta pull --force
 
 Force pull latest changes of deta micro present in the current directory.
 Overwrites the files present in the current directory.`
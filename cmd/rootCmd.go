@@ -26,6 +26,9 @@ Complete documentation available at https://docs.deta.sh`,
 
 	// auth manager
 	authManager = auth.NewManager()
+
+	// space selected with --space instead of the default space
+	spaceFlag string
 )
 
 // Execute xx
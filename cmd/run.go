@@ -8,12 +8,12 @@ import (
 
 	"github.com/deta/deta-cli/api"
 
-	"github.com/deta/deta-cli/runtime"
 	"github.com/spf13/cobra"
 )
 
 var (
 	showLogs bool
+	runLocal bool
 	runCmd   = &cobra.Command{
 		Use:     "run [flags] [action] [-- <input args>]",
 		Short:   "Run a deta micro",
@@ -24,16 +24,23 @@ var (
 
 func init() {
 	runCmd.Flags().BoolVarP(&showLogs, "logs", "l", false, "show micro logs")
+	runCmd.Flags().BoolVar(&runLocal, "local", false, "run the micro served locally with deta dev")
+	runCmd.Flags().IntVar(&devPort, "port", defaultDevPort, "port of the local server with --local")
+	runCmd.Flags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
 	rootCmd.AddCommand(runCmd)
 }
 
 func run(cmd *cobra.Command, args []string) error {
+	if runLocal {
+		return runDev(args)
+	}
+
 	wd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	runtimeManager, err := newProfileManager(&wd, false)
 	if err != nil {
 		return err
 	}
@@ -44,7 +51,7 @@ func run(cmd *cobra.Command, args []string) error {
 	}
 
 	if !isInitialized {
-		return fmt.Errorf(fmt.Sprintf("No deta micro present in '%s'", wd))
+		return notInitializedError(wd)
 	}
 
 	progInfo, err := runtimeManager.GetProgInfo()
@@ -78,6 +85,27 @@ func run(cmd *cobra.Command, args []string) error {
 	return printResponse(res.Payload, res.Logs)
 }
 
+// runDev runs the micro served locally with deta dev
+func runDev(args []string) error {
+	action, progArgs := parseArgs(args)
+
+	body, err := json.Marshal(progArgs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Running local micro...")
+	fmt.Println()
+	res, err := invokeDev(&api.InvokeProgRequest{
+		Action: action,
+		Body:   string(body),
+	})
+	if err != nil {
+		return err
+	}
+	return printResponse(res.Payload, res.Logs)
+}
+
 func parseArgs(args []string) (string, map[string]interface{}) {
 	var action string
 	progInput := make(map[string]interface{})
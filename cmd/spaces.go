@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/deta/deta-cli/api"
+	"github.com/deta/deta-cli/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	spacesCmd = &cobra.Command{
+		Use:   "spaces",
+		Short: "List and select deta spaces",
+		RunE:  listSpaces,
+		Args:  cobra.NoArgs,
+	}
+
+	spacesListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List deta spaces",
+		RunE:  listSpaces,
+		Args:  cobra.NoArgs,
+	}
+
+	spacesUseCmd = &cobra.Command{
+		Use:     "use <space>",
+		Short:   "Select the default deta space",
+		RunE:    useSpace,
+		Example: spacesUseExamples(),
+		Args:    cobra.ExactArgs(1),
+	}
+)
+
+func init() {
+	spacesCmd.AddCommand(spacesListCmd)
+	spacesCmd.AddCommand(spacesUseCmd)
+	rootCmd.AddCommand(spacesCmd)
+}
+
+func listSpaces(cmd *cobra.Command, args []string) error {
+	runtimeManager, err := runtime.NewManager(nil, false)
+	if err != nil {
+		return err
+	}
+
+	u, err := getUserInfo(runtimeManager, client)
+	if err != nil {
+		return err
+	}
+
+	spaces, err := client.ListSpaces()
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatSpacesTable(spaces, u.DefaultSpace))
+	return nil
+}
+
+func useSpace(cmd *cobra.Command, args []string) error {
+	runtimeManager, err := runtime.NewManager(nil, false)
+	if err != nil {
+		return err
+	}
+
+	space, err := findSpace(args[0])
+	if err != nil {
+		return err
+	}
+
+	u, err := runtimeManager.GetUserInfo()
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		u = &runtime.UserInfo{
+			DefaultProject: runtime.DefaultProject,
+		}
+	}
+	u.DefaultSpace = space.SpaceID
+	u.DefaultSpaceName = space.Name
+	err = runtimeManager.StoreUserInfo(u)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Successfully selected space '%s' as the default space\n", space.Name)
+	return nil
+}
+
+// findSpace finds a space of the user by name or id
+func findSpace(space string) (*api.ListSpaceItem, error) {
+	spaces, err := client.ListSpaces()
+	if err != nil {
+		return nil, err
+	}
+	return matchSpace(spaces, space)
+}
+
+// matchSpace gets the space with name or id space from spaces
+func matchSpace(spaces api.ListSpacesResponse, space string) (*api.ListSpaceItem, error) {
+	for i, s := range spaces {
+		if s.Name == space || strconv.FormatInt(s.SpaceID, 10) == space {
+			return &spaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("space '%s' not found, see `deta spaces`", space)
+}
+
+// formatSpacesTable formats spaces as a table marking the default space
+func formatSpacesTable(spaces api.ListSpacesResponse, defaultSpace int64) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tNAME\tID\tROLE")
+	for _, s := range spaces {
+		current := ""
+		if s.SpaceID == defaultSpace {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", current, s.Name, s.SpaceID, s.Role)
+	}
+	w.Flush()
+	return b.String()
+}
+
+func spacesUseExamples() string {
+	return `
+1. deta spaces use my-org
+
+Use the space 'my-org' as the default space for all commands.
+
+2. deta projects --space my-org
+
+Use the space 'my-org' only for a single command.`
+}
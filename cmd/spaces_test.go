@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/deta/deta-cli/api"
+	"gotest.tools/v3/assert"
+)
+
+func TestMatchSpace(t *testing.T) {
+	spaces := api.ListSpacesResponse{
+		{SpaceID: 1234, Name: "me", Role: "owner"},
+		{SpaceID: 5678, Name: "my-org", Role: "member"},
+	}
+
+	testCases := []struct {
+		space    string
+		expected int64
+		err      string
+	}{
+		{"me", 1234, ""},
+		{"my-org", 5678, ""},
+		{"5678", 5678, ""},
+		{"other", 0, "space 'other' not found"},
+	}
+	for _, tc := range testCases {
+		s, err := matchSpace(spaces, tc.space)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err, tc.space)
+			continue
+		}
+		assert.NilError(t, err, tc.space)
+		assert.Equal(t, tc.expected, s.SpaceID, tc.space)
+	}
+
+	expected := "" +
+		"   NAME    ID    ROLE\n" +
+		"   me      1234  owner\n" +
+		"*  my-org  5678  member\n"
+	assert.Equal(t, expected, formatSpacesTable(spaces, 5678))
+}
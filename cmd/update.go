@@ -5,7 +5,6 @@ import (
 	"os"
 
 	"github.com/deta/deta-cli/api"
-
 	"github.com/deta/deta-cli/runtime"
 
 	"github.com/spf13/cobra"
@@ -24,14 +23,16 @@ var (
 )
 
 func init() {
-	updateCmd.Flags().StringVarP(&envsPath, "env", "e", "", "path to env file")
+	updateCmd.Flags().StringVarP(&envsPath, "env", "e", "", "path to env file, defaults to '.deta/envs/<profile>/.env' with --env-profile")
 	updateCmd.Flags().StringVarP(&progName, "name", "n", "", "new name of the micro")
 	updateCmd.Flags().StringVarP(&runtimeName, "runtime", "r", "", "runtime version\n\tPython: python3.7, python3.8, python3.9\n\tNode: nodejs12, nodejs14")
+	updateCmd.Flags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
 	rootCmd.AddCommand(updateCmd)
 }
 
 func update(cmd *cobra.Command, args []string) error {
-	if len(progName) == 0 && len(envsPath) == 0 && len(runtimeName) == 0 {
+	// the env file of the env profile is used if no other flags are set
+	if len(progName) == 0 && len(envsPath) == 0 && len(runtimeName) == 0 && envProfile == "" {
 		cmd.Usage()
 		return nil
 	}
@@ -44,7 +45,7 @@ func update(cmd *cobra.Command, args []string) error {
 		wd = args[0]
 	}
 
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	runtimeManager, err := newProfileManager(&wd, false)
 	if err != nil {
 		return err
 	}
@@ -54,7 +55,7 @@ func update(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if !isInitialized {
-		return fmt.Errorf("no deta micro initialized in `%s'", wd)
+		return notInitializedError(wd)
 	}
 
 	progInfo, err := runtimeManager.GetProgInfo()
@@ -62,6 +63,17 @@ func update(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if len(envsPath) == 0 {
+		envsPath, err = runtimeManager.ProfileEnvFile()
+		if err != nil {
+			return err
+		}
+	}
+	if len(progName) == 0 && len(envsPath) == 0 && len(runtimeName) == 0 {
+		cmd.Usage()
+		return nil
+	}
+
 	if len(progName) != 0 {
 		fmt.Println("Updating the name...")
 		err := client.UpdateProgName(&api.UpdateProgNameRequest{
@@ -83,34 +95,10 @@ func update(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to update env vars: %v", err)
 		}
-		vars := make(map[string]*string)
-		for k, v := range envChanges.Vars {
-			// cant' take the address of iterated value directly
-			value := v
-			vars[k] = &value
-		}
-		for _, d := range envChanges.Removed {
-			vars[d] = nil
-		}
-
-		err = client.UpdateProgEnvs(&api.UpdateProgEnvsRequest{
-			ProgramID: progInfo.ID,
-			Account:   progInfo.Account,
-			Region:    progInfo.Region,
-			Vars:      vars,
-		})
+		err = updateProgEnvs(runtimeManager, progInfo, envChanges)
 		if err != nil {
 			return err
 		}
-		for k := range envChanges.Vars {
-			if !inSlice(progInfo.Envs, k) {
-				progInfo.Envs = append(progInfo.Envs, k)
-			}
-		}
-		for _, d := range envChanges.Removed {
-			progInfo.Envs = removeFromSlice(progInfo.Envs, d)
-		}
-		runtimeManager.StoreProgInfo(progInfo)
 
 		fmt.Println("Successfully updated micro's environment variables")
 	}
@@ -138,6 +126,38 @@ func update(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// updateProgEnvs updates the env vars of the micro with the env changes and stores the env keys
+func updateProgEnvs(m *runtime.Manager, p *runtime.ProgInfo, envChanges *runtime.EnvChanges) error {
+	vars := make(map[string]*string)
+	for k, v := range envChanges.Vars {
+		// cant' take the address of iterated value directly
+		value := v
+		vars[k] = &value
+	}
+	for _, d := range envChanges.Removed {
+		vars[d] = nil
+	}
+
+	err := client.UpdateProgEnvs(&api.UpdateProgEnvsRequest{
+		ProgramID: p.ID,
+		Account:   p.Account,
+		Region:    p.Region,
+		Vars:      vars,
+	})
+	if err != nil {
+		return err
+	}
+	for k := range envChanges.Vars {
+		if !inSlice(p.Envs, k) {
+			p.Envs = append(p.Envs, k)
+		}
+	}
+	for _, d := range envChanges.Removed {
+		p.Envs = removeFromSlice(p.Envs, d)
+	}
+	return m.StoreProgInfo(p)
+}
+
 func updateExamples() string {
 	return `
 1. deta update --name a-new-name
@@ -154,5 +174,10 @@ File 'env-file' must have env vars of format 'key=value'.
 Update the runtime of a deta micro.
 Available runtimes:
 	Python: python3.7, python3.8, python3.9
-	Node: nodejs12, nodejs14`
+	Node: nodejs12, nodejs14
+
+4. deta update --env-profile staging
+
+Update the enviroment variables of the micro of the env profile 'staging' from its env file '.deta/envs/staging/.env'.
+Env vars added to or removed from the env file are also updated on ` + "`deta deploy --env-profile staging`" + `.`
 }
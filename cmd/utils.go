@@ -1,19 +1,39 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"os"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/deta/deta-cli/api"
 	"github.com/deta/deta-cli/runtime"
 )
 
+const (
+	// output formats
+	textOutput = "text"
+	jsonOutput = "json"
+
+	// usage of the --env-profile flag
+	envProfileUsage = "env profile linking the directory to another micro, e.g. 'staging'"
+
+	// usage of the --space flag
+	spaceUsage = "name or id of the space to use instead of the default space"
+	// usage of the --space flag of commands of an existing micro
+	progSpaceUsage = "name or id of the space of the micro, only used if the space of the micro is not known"
+)
+
 var (
 	// set with make file during compilation
 	gatewayDomain string
+
+	// env profile selected with --env-profile
+	envProfile string
 )
 
 type progDetailsOutput struct {
@@ -28,10 +48,13 @@ type progDetailsOutput struct {
 	Visor    string   `json:"visor"`
 	Auth     string   `json:"http_auth"`
 	Cron     string   `json:"cron,omitempty"`
+
+	Schedules []*scheduleOutput `json:"schedules,omitempty"`
 }
 
-func progInfoToOutput(p *runtime.ProgInfo) (string, error) {
-	o := progDetailsOutput{
+// newProgDetailsOutput gets the output of the details of a micro
+func newProgDetailsOutput(p *runtime.ProgInfo) *progDetailsOutput {
+	o := &progDetailsOutput{
 		Name:    p.Name,
 		ID:      p.ID,
 		Project: p.Project,
@@ -44,6 +67,12 @@ func progInfoToOutput(p *runtime.ProgInfo) (string, error) {
 		Cron:    p.Cron,
 	}
 
+	for _, s := range newSchedulesOutput(p) {
+		if s.Name != "" {
+			o.Schedules = append(o.Schedules, s)
+		}
+	}
+
 	o.Endpoint = fmt.Sprintf("https://%s.%s", p.Path, gatewayDomain)
 	if p.Visor == "off" {
 		o.Visor = "disabled"
@@ -51,8 +80,11 @@ func progInfoToOutput(p *runtime.ProgInfo) (string, error) {
 	if p.Public {
 		o.Auth = "disabled"
 	}
+	return o
+}
 
-	po, err := prettyPrint(o)
+func progInfoToOutput(p *runtime.ProgInfo) (string, error) {
+	po, err := prettyPrint(newProgDetailsOutput(p))
 	if err != nil {
 		return "", err
 	}
@@ -67,6 +99,20 @@ func prettyPrint(data interface{}) (string, error) {
 	return string(marshalled), nil
 }
 
+// formatSize formats size in bytes to a human readable size
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
 func removeFromSlice(slice []string, toRemove string) []string {
 	for i, s := range slice {
 		if s == toRemove {
@@ -98,13 +144,14 @@ func areSlicesEqualNoOrder(a, b []string) bool {
 
 // get user info from local storage if cached otherwise from server
 // saves user info to local storage if not cached
+// the default space is replaced with the space selected with --space
 func getUserInfo(rm *runtime.Manager, client *api.DetaClient) (*runtime.UserInfo, error) {
 	u, err := rm.GetUserInfo()
 	if err != nil {
 		return nil, err
 	}
 	if u != nil {
-		return u, nil
+		return withSelectedSpace(u)
 	}
 
 	// fall back to server
@@ -120,7 +167,44 @@ func getUserInfo(rm *runtime.Manager, client *api.DetaClient) (*runtime.UserInfo
 		DefaultProject:   userInfo.DefaultProject,
 	}
 	go rm.StoreUserInfo(u)
-	return u, nil
+	return withSelectedSpace(u)
+}
+
+// withSelectedSpace gets a copy of the user info with the space selected with --space as the default space
+func withSelectedSpace(u *runtime.UserInfo) (*runtime.UserInfo, error) {
+	if spaceFlag == "" {
+		return u, nil
+	}
+	space, err := findSpace(spaceFlag)
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.UserInfo{
+		DefaultSpace:     space.SpaceID,
+		DefaultSpaceName: space.Name,
+		DefaultProject:   u.DefaultProject,
+	}, nil
+}
+
+// getProgUserInfo gets the user info with the space of the micro as the default space
+// the space selected with --space is only used if the space of the micro is not known
+func getProgUserInfo(rm *runtime.Manager, p *runtime.ProgInfo) (*runtime.UserInfo, error) {
+	u, err := getUserInfo(rm, client)
+	if err != nil {
+		return nil, err
+	}
+	if p.Space == 0 || p.Space == u.DefaultSpace {
+		return u, nil
+	}
+	space, err := findSpace(strconv.FormatInt(p.Space, 10))
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.UserInfo{
+		DefaultSpace:     space.SpaceID,
+		DefaultSpaceName: space.Name,
+		DefaultProject:   u.DefaultProject,
+	}, nil
 }
 
 // parseRuntime takes runtimeName as string and returns Runtine struct
@@ -137,3 +221,94 @@ func parseRuntime(runtimeName string) (*runtime.Runtime, error) {
 
 	return progRuntime, nil
 }
+
+// newProfileManager gets a runtime manager for wd using the env profile selected with --env-profile
+func newProfileManager(wd *string, initDirs bool) (*runtime.Manager, error) {
+	runtimeManager, err := runtime.NewManager(wd, initDirs)
+	if err != nil {
+		return nil, err
+	}
+	runtimeManager.SetCLIVersion(detaVersion)
+	err = runtimeManager.SetProfile(envProfile)
+	if err != nil {
+		return nil, err
+	}
+	return runtimeManager, nil
+}
+
+// notInitializedError error for no micro initialized in wd with the selected env profile
+func notInitializedError(wd string) error {
+	if envProfile != "" {
+		return fmt.Errorf("no deta micro initialized for env profile '%s' in '%s', see `deta new --help`", envProfile, wd)
+	}
+	return fmt.Errorf("no deta micro initialized in '%s'", wd)
+}
+
+// progInfoFromDetails gets the program info of a micro from its details and cron expression
+func progInfoFromDetails(d *api.GetProgDetailsResponse, cron string) *runtime.ProgInfo {
+	return &runtime.ProgInfo{
+		ID:      d.ID,
+		Space:   d.Space,
+		Runtime: d.Runtime,
+		Name:    d.Name,
+		Path:    d.Path,
+		Project: d.Project,
+		Account: d.Account,
+		Region:  d.Region,
+		Deps:    d.Deps,
+		Envs:    d.Envs,
+		Public:  d.Public,
+		Visor:   d.Visor,
+		Cron:    cron,
+	}
+}
+
+// setProgSchedules sets the default and named schedules of the program info from the server
+// failing to get the schedules is only a warning, they are refreshed with `deta cron list`
+func setProgSchedules(p *runtime.ProgInfo) {
+	cron, schedules, err := getSchedules(p.ID)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("Failed to get the schedules of the micro, see `deta cron list`: %v\n", err))
+		return
+	}
+	p.Cron, p.Schedules = cron, schedules
+}
+
+// getSchedules gets the expression of the default schedule and the named schedules of a program from the server
+func getSchedules(programID string) (string, []*runtime.Schedule, error) {
+	res, err := client.GetSchedules(&api.GetSchedulesRequest{
+		ProgramID: programID,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	var cronExpression string
+	var schedules []*runtime.Schedule
+	for _, s := range res.Schedules {
+		// the default schedule is stored as the cron of the program
+		if s.Name == "" {
+			cronExpression = s.Expression
+			continue
+		}
+		schedules = append(schedules, &runtime.Schedule{
+			Name:       s.Name,
+			Expression: s.Expression,
+			Action:     s.Action,
+			Body:       s.Body,
+		})
+	}
+	return cronExpression, schedules, nil
+}
+
+// confirmName asks to type name to confirm an action, unless name was already given with confirm
+func confirmName(name, confirm string) (bool, error) {
+	if confirm == "" {
+		fmt.Printf("Type the name '%s' to confirm: ", name)
+		var err error
+		confirm, err = bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return false, fmt.Errorf("failed to read confirmation: %v", err)
+		}
+	}
+	return strings.TrimSpace(confirm) == name, nil
+}
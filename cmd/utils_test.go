@@ -27,3 +27,19 @@ func TestAreSlicesEqualNoOrder(t *testing.T) {
 		assert.Equal(t, tc.equal, areSlicesEqualNoOrder(tc.a, tc.b), msg)
 	}
 }
+
+func TestFormatSize(t *testing.T) {
+	testCases := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, formatSize(tc.size), fmt.Sprintf("size: %d", tc.size))
+	}
+}
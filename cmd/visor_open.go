@@ -24,6 +24,7 @@ var (
 )
 
 func init() {
+	visorOpenCmd.Flags().StringVar(&spaceFlag, "space", "", progSpaceUsage)
 	visorCmd.AddCommand(visorOpenCmd)
 }
 
@@ -49,12 +50,12 @@ func visorOpen(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf(fmt.Sprintf("no deta micro present in '%s'", wd))
 	}
 
-	userInfo, err := getUserInfo(runtimeManager, client)
+	progInfo, err := runtimeManager.GetProgInfo()
 	if err != nil {
 		return err
 	}
 
-	progInfo, err := runtimeManager.GetProgInfo()
+	userInfo, err := getProgUserInfo(runtimeManager, progInfo)
 	if err != nil {
 		return err
 	}
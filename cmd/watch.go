@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/deta/deta-cli/runtime"
@@ -22,6 +26,12 @@ var (
 )
 
 func init() {
+	watchCmd.Flags().BoolVar(&rehash, "rehash", false, "hash all files to check for changes, even if unchanged since last deploy")
+	watchCmd.Flags().IntVar(&hashConcurrency, "hash-concurrency", 0, "max number of files hashed concurrently, defaults to the number of cpus")
+	watchCmd.Flags().BoolVarP(&forceDeploy, "force", "f", false, "deploy even if the deployed code changed since the last deploy or pull")
+	watchCmd.Flags().BoolVar(&deployAll, "all", false, "watch all micros in the path and its sub directories")
+	watchCmd.Flags().IntVar(&deployParallel, "parallel", defaultParallel, "max number of micros deployed concurrently on the initial deployment with --all")
+	watchCmd.Flags().StringVar(&envProfile, "env-profile", "", envProfileUsage)
 	rootCmd.AddCommand(watchCmd)
 }
 
@@ -33,11 +43,19 @@ func watch(cmd *cobra.Command, args []string) error {
 	if len(args) != 0 {
 		wd = args[0]
 	}
+	if deployAll {
+		if deployParallel < 1 {
+			return fmt.Errorf("invalid value %d for --parallel, must be at least 1", deployParallel)
+		}
+		return watchAllMicros(wd)
+	}
 
-	runtimeManager, err := runtime.NewManager(&wd, false)
+	runtimeManager, err := newProfileManager(&wd, false)
 	if err != nil {
 		return err
 	}
+	runtimeManager.SetRehash(rehash)
+	runtimeManager.SetConcurrency(hashConcurrency)
 
 	isInitialized, err := runtimeManager.IsInitialized()
 	if err != nil {
@@ -45,7 +63,7 @@ func watch(cmd *cobra.Command, args []string) error {
 	}
 
 	if !isInitialized {
-		return fmt.Errorf(fmt.Sprintf("no deta micro initilialized in '%s'. see `deta new --help`", wd))
+		return notInitializedError(wd)
 	}
 
 	progInfo, err := runtimeManager.GetProgInfo()
@@ -54,29 +72,143 @@ func watch(cmd *cobra.Command, args []string) error {
 	}
 
 	// do an initial deployment
-	err = deployChanges(runtimeManager, progInfo, true)
+	// drift is only checked before the initial deployment, later deployments only overwrite its own
+	err = deployInitialChanges(runtimeManager, progInfo, os.Stdout)
 	if err != nil {
 		return err
 	}
 
 	c := make(chan notify.EventInfo, 1)
 
-	// {dir}/... watch dir recursively
-	if err := notify.Watch(filepath.Join(wd, "..."), c, notify.Write); err != nil {
+	includeDirs, err := runtimeManager.IncludeDirs()
+	if err != nil {
 		return err
 	}
+	// {dir}/... watch dir recursively
+	for _, dir := range append([]string{wd}, includeDirs...) {
+		if err := notify.Watch(filepath.Join(dir, "..."), c, notify.Write); err != nil {
+			return err
+		}
+	}
 
 	fmt.Println("Watching changes")
 	for {
 		<-c
 		time.Sleep(100 * time.Millisecond)
-		err := deployChanges(runtimeManager, progInfo, true)
+		err := deployChanges(runtimeManager, progInfo, true, os.Stdout)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// watchAllMicros deploys changes of all micros in root and its sub dirs in real time
+// failed deployments of a micro do not stop watching
+func watchAllMicros(root string) error {
+	// paths of events are absolute with symlinks resolved
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+
+	dirs, err := findMicros(root)
+	if err != nil {
+		return err
+	}
+	managers := make(map[string]*runtime.Manager, len(dirs))
+	progInfos := make(map[string]*runtime.ProgInfo, len(dirs))
+	// watched dirs mapped to the micros deployed on changes in the dir
+	watched := make(map[string][]string)
+	for _, dir := range dirs {
+		managers[dir], progInfos[dir], err = newDeployManager(dir)
 		if err != nil {
 			return err
 		}
+		watched[dir] = append(watched[dir], dir)
+
+		includeDirs, err := managers[dir].IncludeDirs()
+		if err != nil {
+			return err
+		}
+		for _, includeDir := range includeDirs {
+			includeDir, err = filepath.EvalSymlinks(includeDir)
+			if err != nil {
+				return err
+			}
+			watched[includeDir] = append(watched[includeDir], dir)
+		}
+	}
+
+	// do an initial deployment
+	results := runMicros(root, dirs, deployParallel, func(dir string, w io.Writer) error {
+		return deployInitialChanges(managers[dir], progInfos[dir], w)
+	})
+	summary, _ := formatMicroSummary(results)
+	fmt.Print(summary)
+
+	c := make(chan notify.EventInfo, 1)
+
+	// {dir}/... watch dir recursively
+	for dir := range watched {
+		if err := notify.Watch(filepath.Join(dir, "..."), c, notify.Write); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Watching changes of %d micros\n", len(dirs))
+	for {
+		e := <-c
+		micros := microsForPath(watched, e.Path())
+		if len(micros) == 0 {
+			continue
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		for _, dir := range micros {
+			var buf bytes.Buffer
+			err := deployChanges(managers[dir], progInfos[dir], true, &buf)
+			// nothing is printed if there were no changes
+			if buf.Len() > 0 || err != nil {
+				printMicroOutput(&microResult{name: microName(root, dir), err: err}, buf.Bytes())
+			}
+		}
 	}
 }
 
+// deployInitialChanges deploys the changes when starting to watch a micro
+// checking for drift first
+func deployInitialChanges(m *runtime.Manager, p *runtime.ProgInfo, w io.Writer) error {
+	err := checkDrift(m, p, forceDeploy, w)
+	if err != nil {
+		return err
+	}
+	return deployChanges(m, p, true, w)
+}
+
+// microsForPath gets the micros deployed on changes of path from the watched dirs, sorted by dir
+func microsForPath(watched map[string][]string, path string) []string {
+	found := make(map[string]struct{})
+	for dir, micros := range watched {
+		if path != dir && !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			continue
+		}
+		for _, m := range micros {
+			found[m] = struct{}{}
+		}
+	}
+
+	micros := make([]string, 0, len(found))
+	for m := range found {
+		micros = append(micros, m)
+	}
+	sort.Strings(micros)
+	return micros
+}
+
 func watchExamples() string {
 	return `
 1. deta watch
@@ -85,5 +217,11 @@ Watch for changes in the current directory and deploy changes in real time.
 
 2. deta watch my-micro
 
-Watch for changes in './my-micro' directory and deploy changes in real time.`
+Watch for changes in './my-micro' directory and deploy changes in real time.
+
+3. deta watch --all micros
+
+Watch for changes of all micros in 'micros' and its sub directories and deploy changes in real time.
+
+Directories included with the 'include' field of the manifest are also watched.`
 }
@@ -0,0 +1,447 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TypeRate type of rate expressions, e.g. '5 minutes'
+	TypeRate = "rate"
+	// TypeCron type of cron expressions, e.g. '0 10 * * ? *'
+	TypeCron = "cron"
+
+	// range of years of cron expressions
+	minYear = 1970
+	maxYear = 2199
+)
+
+var (
+	// ErrInvalidExpression expression is neither a rate nor a cron expression
+	ErrInvalidExpression = errors.New("invalid expression")
+
+	// maps units of rate expressions to durations
+	rateUnits = map[string]time.Duration{
+		"minute": time.Minute,
+		"hour":   time.Hour,
+		"day":    24 * time.Hour,
+	}
+
+	monthNames = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+	dayNames   = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+)
+
+// Schedule a parsed schedule expression
+type Schedule interface {
+	// Next gets the first time the schedule fires after t in the location of t
+	// returns the zero time if the schedule never fires after t
+	Next(t time.Time) time.Time
+}
+
+// Type gets the type of an expression by its number of fields
+func Type(expr string) (string, error) {
+	n := len(strings.Fields(expr))
+	switch n {
+	case 2:
+		return TypeRate, nil
+	case 6:
+		return TypeCron, nil
+	}
+	return "", fmt.Errorf("%w: expected 2 fields for a rate or 6 fields for a cron expression, got %d", ErrInvalidExpression, n)
+}
+
+// Parse parses a rate expression like '5 minutes' or a cron expression with six fields
+// cron expressions have the fields minutes, hours, day-of-month, month, day-of-week and year
+func Parse(expr string) (Schedule, error) {
+	t, err := Type(expr)
+	if err != nil {
+		return nil, err
+	}
+	if t == TypeRate {
+		return parseRate(expr)
+	}
+	return parseCron(expr)
+}
+
+// Normalize normalizes an expression to the form sent to the server
+// fields are separated by single spaces, names of cron fields are uppercase and units of rates lowercase
+func Normalize(expr string) (string, error) {
+	t, err := Type(expr)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Fields(expr)
+	if t == TypeRate {
+		parts[1] = strings.ToLower(parts[1])
+	} else {
+		for i := range parts {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// fieldError error of a field of an expression
+func fieldError(field, value, reason string) error {
+	return fmt.Errorf("%w: %s '%s' %s", ErrInvalidExpression, field, value, reason)
+}
+
+// rateSchedule fires every interval
+type rateSchedule struct {
+	interval time.Duration
+}
+
+func parseRate(expr string) (*rateSchedule, error) {
+	parts := strings.Fields(expr)
+	value, err := strconv.Atoi(parts[0])
+	if err != nil || value < 1 {
+		return nil, fieldError("rate", parts[0], "must be a positive number")
+	}
+	unit := strings.ToLower(parts[1])
+	// the unit is singular for a value of 1 and plural otherwise
+	if value == 1 && strings.HasSuffix(unit, "s") {
+		return nil, fieldError("unit", unit, "must be singular for a rate of 1")
+	}
+	if value > 1 {
+		if !strings.HasSuffix(unit, "s") {
+			return nil, fieldError("unit", unit, fmt.Sprintf("must be plural for a rate of %d", value))
+		}
+		unit = strings.TrimSuffix(unit, "s")
+	}
+	d, ok := rateUnits[unit]
+	if !ok {
+		return nil, fieldError("unit", parts[1], "must be minute, hour or day")
+	}
+	return &rateSchedule{
+		interval: time.Duration(value) * d,
+	}, nil
+}
+
+// Next gets the time one interval after t, truncated to the minute
+func (r *rateSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Minute).Add(r.interval)
+}
+
+// bits set of values of a field
+type bits []bool
+
+// field of a cron expression
+type field struct {
+	name  string
+	min   int
+	max   int
+	names []string // names of values starting at min
+}
+
+var (
+	minutesField    = field{name: "minutes", min: 0, max: 59}
+	hoursField      = field{name: "hours", min: 0, max: 23}
+	dayOfMonthField = field{name: "day-of-month", min: 1, max: 31}
+	monthField      = field{name: "month", min: 1, max: 12, names: monthNames}
+	dayOfWeekField  = field{name: "day-of-week", min: 1, max: 7, names: dayNames}
+	yearField       = field{name: "year", min: minYear, max: maxYear}
+)
+
+// value parses a single value of the field, a number or a name
+func (f *field) value(s string) (int, error) {
+	for i, n := range f.names {
+		if strings.EqualFold(s, n) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fieldError(f.name, s, "is not a valid value")
+	}
+	if v < f.min || v > f.max {
+		return 0, fieldError(f.name, s, fmt.Sprintf("is out of range %d-%d", f.min, f.max))
+	}
+	return v, nil
+}
+
+// parse parses a list of values, ranges and steps of the field
+func (f *field) parse(s string) (bits, error) {
+	b := make(bits, f.max+1)
+	for _, part := range strings.Split(s, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			v, err := strconv.Atoi(part[i+1:])
+			if err != nil || v < 1 {
+				return nil, fieldError(f.name, part, "has an invalid step")
+			}
+			step = v
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			start, err = f.value(bounds[0])
+			if err != nil {
+				return nil, err
+			}
+			end, err = f.value(bounds[1])
+			if err != nil {
+				return nil, err
+			}
+			if start > end {
+				return nil, fieldError(f.name, part, "has a range with the start after the end")
+			}
+		default:
+			v, err := f.value(rangePart)
+			if err != nil {
+				return nil, err
+			}
+			start, end = v, v
+			// a step from a single value continues to the max value
+			if step > 1 || strings.Contains(part, "/") {
+				end = f.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			b[v] = true
+		}
+	}
+	return b, nil
+}
+
+// dayOfMonth day-of-month field of a cron expression
+type dayOfMonth struct {
+	any            bool // '?'
+	values         bits
+	last           bool // 'L', last day of the month
+	lastWeekday    bool // 'LW', last weekday of the month
+	nearestWeekday int  // 'nW', weekday nearest to day n of the month
+}
+
+func parseDayOfMonth(s string) (*dayOfMonth, error) {
+	d := &dayOfMonth{}
+	switch {
+	case s == "?":
+		d.any = true
+	case strings.EqualFold(s, "L"):
+		d.last = true
+	case strings.EqualFold(s, "LW"):
+		d.lastWeekday = true
+	case strings.HasSuffix(strings.ToUpper(s), "W"):
+		v, err := dayOfMonthField.value(s[:len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+		d.nearestWeekday = v
+	default:
+		values, err := dayOfMonthField.parse(s)
+		if err != nil {
+			return nil, err
+		}
+		d.values = values
+	}
+	return d, nil
+}
+
+// matches checks if day is matched by the field
+func (d *dayOfMonth) matches(day time.Time) bool {
+	last := lastDayOfMonth(day)
+	switch {
+	case d.any:
+		return true
+	case d.last:
+		return day.Day() == last
+	case d.lastWeekday:
+		return day.Day() == nearestWeekday(day, last)
+	case d.nearestWeekday > 0:
+		target := d.nearestWeekday
+		if target > last {
+			return false
+		}
+		return day.Day() == nearestWeekday(day, target)
+	}
+	return d.values[day.Day()]
+}
+
+// dayOfWeek day-of-week field of a cron expression
+type dayOfWeek struct {
+	any     bool // '?'
+	values  bits
+	last    int // 'nL', last weekday n of the month
+	nth     int // 'n#k', k-th weekday n of the month
+	nthWeek int
+}
+
+func parseDayOfWeek(s string) (*dayOfWeek, error) {
+	d := &dayOfWeek{}
+	switch {
+	case s == "?":
+		d.any = true
+	case strings.EqualFold(s, "L"):
+		d.last = 7
+	case len(s) > 1 && strings.HasSuffix(strings.ToUpper(s), "L"):
+		v, err := dayOfWeekField.value(s[:len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+		d.last = v
+	case strings.Contains(s, "#"):
+		parts := strings.SplitN(s, "#", 2)
+		v, err := dayOfWeekField.value(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		week, err := strconv.Atoi(parts[1])
+		if err != nil || week < 1 || week > 5 {
+			return nil, fieldError(dayOfWeekField.name, s, "must have a week of the month from 1 to 5")
+		}
+		d.nth, d.nthWeek = v, week
+	default:
+		values, err := dayOfWeekField.parse(s)
+		if err != nil {
+			return nil, err
+		}
+		d.values = values
+	}
+	return d, nil
+}
+
+// matches checks if day is matched by the field
+func (d *dayOfWeek) matches(day time.Time) bool {
+	// days of week are from 1 (SUN) to 7 (SAT)
+	weekday := int(day.Weekday()) + 1
+	switch {
+	case d.any:
+		return true
+	case d.last > 0:
+		return weekday == d.last && day.Day()+7 > lastDayOfMonth(day)
+	case d.nth > 0:
+		return weekday == d.nth && (day.Day()-1)/7+1 == d.nthWeek
+	}
+	return d.values[weekday]
+}
+
+// lastDayOfMonth gets the last day of the month of t
+func lastDayOfMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// nearestWeekday gets the weekday nearest to day target in the month of t without leaving the month
+func nearestWeekday(t time.Time, target int) int {
+	last := lastDayOfMonth(t)
+	switch time.Date(t.Year(), t.Month(), target, 0, 0, 0, 0, t.Location()).Weekday() {
+	case time.Saturday:
+		if target == 1 {
+			return target + 2
+		}
+		return target - 1
+	case time.Sunday:
+		if target == last {
+			return target - 2
+		}
+		return target + 1
+	}
+	return target
+}
+
+// cronSchedule fires at the times matched by a cron expression
+type cronSchedule struct {
+	minutes    bits
+	hours      bits
+	dayOfMonth *dayOfMonth
+	months     bits
+	dayOfWeek  *dayOfWeek
+	years      bits
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	parts := strings.Fields(expr)
+
+	// one of day-of-month and day-of-week must be '?'
+	if (parts[2] == "?") == (parts[4] == "?") {
+		return nil, fmt.Errorf("%w: one of day-of-month '%s' and day-of-week '%s' must be '?'", ErrInvalidExpression, parts[2], parts[4])
+	}
+
+	var c cronSchedule
+	var err error
+	c.minutes, err = minutesField.parse(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	c.hours, err = hoursField.parse(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	c.dayOfMonth, err = parseDayOfMonth(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	c.months, err = monthField.parse(parts[3])
+	if err != nil {
+		return nil, err
+	}
+	c.dayOfWeek, err = parseDayOfWeek(parts[4])
+	if err != nil {
+		return nil, err
+	}
+	c.years, err = yearField.parse(parts[5])
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Next gets the first time matched by the expression after t
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	for day.Year() <= maxYear {
+		if day.Year() < minYear || !c.years[day.Year()] {
+			day = time.Date(day.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.months[int(day.Month())] {
+			day = time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.dayOfMonth.matches(day) && c.dayOfWeek.matches(day) {
+			for h := 0; h < len(c.hours); h++ {
+				if !c.hours[h] {
+					continue
+				}
+				for m := 0; m < len(c.minutes); m++ {
+					if !c.minutes[m] {
+						continue
+					}
+					next := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, loc)
+					// skip times not present in the location, e.g. on daylight saving time changes
+					if next.Hour() != h || next.Before(t) {
+						continue
+					}
+					return next
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// NextN gets the next n times the schedule fires after t
+func NextN(s Schedule, t time.Time, n int) []time.Time {
+	var times []time.Time
+	for i := 0; i < n; i++ {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
@@ -0,0 +1,205 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	tm, err := time.Parse(time.RFC3339, value)
+	assert.NilError(t, err)
+	return tm
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		expr string
+		err  error
+	}{
+		{"1 minute", nil},
+		{"2 hours", nil},
+		{"7 days", nil},
+		{"0 minutes", ErrInvalidExpression},
+		{"1 minutes", ErrInvalidExpression},
+		{"2 hour", ErrInvalidExpression},
+		{"5 weeks", ErrInvalidExpression},
+		{"0 10 * * ? *", nil},
+		{"0/15 * * * ? *", nil},
+		{"0/5 8-17 ? * MON-FRI *", nil},
+		{"15 10 L * ? *", nil},
+		{"0 9 LW * ? *", nil},
+		{"0 9 15W * ? *", nil},
+		{"0 9 ? * 6L *", nil},
+		{"0 9 ? * MON#2 2021-2030", nil},
+		{"0 10 * * * *", ErrInvalidExpression},
+		{"0 10 ? * ? *", ErrInvalidExpression},
+		{"60 10 * * ? *", ErrInvalidExpression},
+		{"0 10 * 13 ? *", ErrInvalidExpression},
+		{"0 10 ? * 8 *", ErrInvalidExpression},
+		{"0 10 ? * MON#6 *", ErrInvalidExpression},
+		{"0 17-8 * * ? *", ErrInvalidExpression},
+		{"0/0 * * * ? *", ErrInvalidExpression},
+		{"0 10 * * ? 1969", ErrInvalidExpression},
+		{"0 10 * * ?", ErrInvalidExpression},
+		{"akdlkf", ErrInvalidExpression},
+	}
+
+	for _, tc := range testCases {
+		_, err := Parse(tc.expr)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("got unexpected error for expression '%s': expected %v got %v", tc.expr, tc.err, err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		expr       string
+		normalized string
+	}{
+		{"5 Minutes", "5 minutes"},
+		{" 1  day ", "1 day"},
+		{"0/5  8-17 ? * mon-fri *", "0/5 8-17 ? * MON-FRI *"},
+		{"0 9 lw jan ? *", "0 9 LW JAN ? *"},
+	}
+
+	for _, tc := range testCases {
+		normalized, err := Normalize(tc.expr)
+		assert.NilError(t, err)
+		assert.Equal(t, normalized, tc.normalized)
+	}
+}
+
+func TestNext(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		from     string
+		expected []string
+	}{
+		{
+			expr: "5 minutes",
+			from: "2021-03-01T10:02:30Z",
+			expected: []string{
+				"2021-03-01T10:07:00Z",
+				"2021-03-01T10:12:00Z",
+			},
+		},
+		{
+			expr: "0/15 * * * ? *",
+			from: "2021-03-01T10:07:00Z",
+			expected: []string{
+				"2021-03-01T10:15:00Z",
+				"2021-03-01T10:30:00Z",
+				"2021-03-01T10:45:00Z",
+				"2021-03-01T11:00:00Z",
+			},
+		},
+		{
+			expr: "0 10 * * ? *",
+			from: "2021-03-01T10:00:00Z",
+			expected: []string{
+				"2021-03-02T10:00:00Z",
+				"2021-03-03T10:00:00Z",
+			},
+		},
+		{
+			// weekdays, 2021-03-05 is a friday
+			expr: "30 8 ? * MON-FRI *",
+			from: "2021-03-05T09:00:00Z",
+			expected: []string{
+				"2021-03-08T08:30:00Z",
+				"2021-03-09T08:30:00Z",
+			},
+		},
+		{
+			expr: "0 0 L * ? *",
+			from: "2021-01-31T00:00:00Z",
+			expected: []string{
+				"2021-02-28T00:00:00Z",
+				"2021-03-31T00:00:00Z",
+				"2021-04-30T00:00:00Z",
+			},
+		},
+		{
+			// 2021-05-31 is a monday, 2021-07-31 is a saturday
+			expr: "0 0 LW * ? *",
+			from: "2021-05-01T00:00:00Z",
+			expected: []string{
+				"2021-05-31T00:00:00Z",
+				"2021-06-30T00:00:00Z",
+				"2021-07-30T00:00:00Z",
+			},
+		},
+		{
+			// 2021-05-01 is a saturday, 2021-08-01 is a sunday
+			expr: "0 0 1W * ? *",
+			from: "2021-04-30T00:00:00Z",
+			expected: []string{
+				"2021-05-03T00:00:00Z",
+				"2021-06-01T00:00:00Z",
+				"2021-07-01T00:00:00Z",
+				"2021-08-02T00:00:00Z",
+			},
+		},
+		{
+			// last friday of the month
+			expr: "0 12 ? * 6L *",
+			from: "2021-03-01T00:00:00Z",
+			expected: []string{
+				"2021-03-26T12:00:00Z",
+				"2021-04-30T12:00:00Z",
+			},
+		},
+		{
+			// second monday of the month
+			expr: "0 12 ? * MON#2 *",
+			from: "2021-03-01T00:00:00Z",
+			expected: []string{
+				"2021-03-08T12:00:00Z",
+				"2021-04-12T12:00:00Z",
+			},
+		},
+		{
+			expr: "0 0 29 FEB ? *",
+			from: "2021-01-01T00:00:00Z",
+			expected: []string{
+				"2024-02-29T00:00:00Z",
+				"2028-02-29T00:00:00Z",
+			},
+		},
+		{
+			expr: "0 0 1 JAN ? 2022",
+			from: "2021-01-01T00:00:00Z",
+			expected: []string{
+				"2022-01-01T00:00:00Z",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s, err := Parse(tc.expr)
+		assert.NilError(t, err)
+
+		var expected []time.Time
+		for _, e := range tc.expected {
+			expected = append(expected, mustTime(t, e))
+		}
+		times := NextN(s, mustTime(t, tc.from), 4)
+		if len(times) > len(expected) {
+			times = times[:len(expected)]
+		}
+		assert.DeepEqual(t, times, expected)
+	}
+}
+
+func TestNextLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s, err := Parse("0 10 * * ? *")
+	assert.NilError(t, err)
+
+	next := s.Next(time.Date(2021, time.March, 1, 9, 0, 0, 0, loc))
+	assert.Assert(t, next.Equal(mustTime(t, "2021-03-01T08:00:00Z")))
+}
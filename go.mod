@@ -7,5 +7,6 @@ require (
 	github.com/rjeczalik/notify v0.9.2
 	github.com/spf13/cobra v1.0.0
 	golang.org/x/sys v0.0.0-20200620081246-981b61492c35
+	gopkg.in/yaml.v2 v2.2.2
 	gotest.tools/v3 v3.0.3
 )
@@ -0,0 +1,341 @@
+package runtime
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// dir under the deta dir storing the files to run micros locally
+	devDir = "dev"
+
+	// dir under the dev dir linking the include dirs at their prefixes
+	devIncludeDir = "include"
+
+	// DevRunPath path of the local server invoking run actions of the micro
+	// the request and response are the same as of the invocations api
+	DevRunPath = "/__deta/run"
+
+	// bootstrap of python micros, serves the wsgi or asgi app of 'main.py'
+	pythonDevEntry = `# written by deta dev, serves the app of main.py locally
+import asyncio
+import contextlib
+import inspect
+import io
+import json
+import os
+import sys
+import traceback
+
+root = os.environ["DETA_DEV_ROOT"]
+port = int(os.environ["DETA_DEV_PORT"])
+run_path = os.environ["DETA_DEV_RUN_PATH"]
+sys.path.insert(0, root)
+os.chdir(root)
+
+from main import app  # noqa: E402
+
+
+async def run_action(data):
+    # invokes the app with the event of a run action like the deployed micro
+    logs = io.StringIO()
+    with contextlib.redirect_stdout(logs), contextlib.redirect_stderr(logs):
+        try:
+            request = json.loads(data or b"{}")
+            event = {"action": request.get("action", ""), "body": json.loads(request.get("body") or "{}")}
+            if not hasattr(app, "lib"):
+                raise Exception("the app of main.py does not handle run actions")
+            result = app(event, None)
+            if inspect.isawaitable(result):
+                result = await result
+            payload = json.dumps(result)
+        except Exception as e:
+            traceback.print_exc()
+            payload = json.dumps({"errorMessage": str(e), "errorType": type(e).__name__})
+    return json.dumps({"payload": payload, "logs": "START\n" + logs.getvalue().rstrip("\n") + "\nEND\nREPORT\n"}).encode()
+
+
+def wsgi_app(environ, start_response):
+    if environ["PATH_INFO"] == run_path and environ["REQUEST_METHOD"] == "POST":
+        length = int(environ.get("CONTENT_LENGTH") or 0)
+        body = asyncio.run(run_action(environ["wsgi.input"].read(length)))
+        start_response("200 OK", [("Content-Type", "application/json")])
+        return [body]
+    return app(environ, start_response)
+
+
+async def asgi_app(scope, receive, send):
+    if scope["type"] == "http" and scope["path"] == run_path and scope["method"] == "POST":
+        data = b""
+        while True:
+            message = await receive()
+            data += message.get("body", b"")
+            if not message.get("more_body"):
+                break
+        body = await run_action(data)
+        await send({"type": "http.response.start", "status": 200, "headers": [(b"content-type", b"application/json")]})
+        await send({"type": "http.response.body", "body": body})
+        return
+    await app(scope, receive, send)
+
+
+if inspect.iscoroutinefunction(app) or inspect.iscoroutinefunction(getattr(app, "__call__", None)):
+    try:
+        import uvicorn
+    except ImportError:
+        sys.exit("the asgi app of main.py needs uvicorn to be served locally, install it with 'pip install uvicorn'")
+
+    uvicorn.run(asgi_app, host="127.0.0.1", port=port)
+else:
+    from wsgiref.simple_server import make_server
+
+    make_server("127.0.0.1", port, wsgi_app).serve_forever()
+`
+
+	// bootstrap of node micros, serves the app exported by 'index.js'
+	nodeDevEntry = `// written by deta dev, serves the app of index.js locally
+const http = require('http');
+const path = require('path');
+const util = require('util');
+
+const root = process.env.DETA_DEV_ROOT;
+const port = parseInt(process.env.DETA_DEV_PORT, 10);
+const runPath = process.env.DETA_DEV_RUN_PATH;
+process.chdir(root);
+
+const app = require(path.join(root, 'index.js'));
+
+// invokes the app with the event of a run action like the deployed micro
+async function runAction(data) {
+  const logs = [];
+  const log = console.log;
+  const error = console.error;
+  console.log = console.error = (...args) => logs.push(util.format(...args));
+  let payload;
+  try {
+    const request = JSON.parse(data || '{}');
+    const event = { action: request.action || '', body: JSON.parse(request.body || '{}') };
+    if (!app.lib) {
+      throw new Error('the app of index.js does not handle run actions');
+    }
+    payload = JSON.stringify(await app(event, {}));
+  } catch (e) {
+    logs.push(e.stack);
+    payload = JSON.stringify({ errorMessage: e.message, errorType: e.name });
+  } finally {
+    console.log = log;
+    console.error = error;
+  }
+  return JSON.stringify({ payload: payload, logs: 'START\n' + logs.join('\n') + '\nEND\nREPORT\n' });
+}
+
+http.createServer((req, res) => {
+  if (req.method === 'POST' && req.url === runPath) {
+    let data = '';
+    req.on('data', (chunk) => { data += chunk; });
+    req.on('end', async () => {
+      res.writeHead(200, { 'Content-Type': 'application/json' });
+      res.end(await runAction(data));
+    });
+    return;
+  }
+  app(req, res);
+}).listen(port, '127.0.0.1');
+`
+)
+
+var (
+	// maps runtimes to the bootstrap files serving micros locally
+	devEntryFiles = map[string]string{
+		Python: "_dev_entry.py",
+		Node:   "_dev_entry.js",
+	}
+
+	// maps runtimes to the contents of the bootstrap files
+	devEntries = map[string]string{
+		Python: pythonDevEntry,
+		Node:   nodeDevEntry,
+	}
+
+	// maps runtimes to the commands running the bootstrap files
+	devCommands = map[string]string{
+		Python: "python3",
+		Node:   "node",
+	}
+)
+
+// DevCommand gets the command serving the micro locally on port with the local toolchain
+// env vars are read from envFile relative to the root dir if not empty
+// the bootstrap serving the app of the entrypoint file is written to the deta dir
+// include dirs are linked at their prefixes in a dir added to PYTHONPATH and NODE_PATH
+// so that they are imported like in the deployed micro
+func (m *Manager) DevCommand(envFile string, port int) (*exec.Cmd, error) {
+	r, err := m.GetRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	var envs map[string]string
+	if envFile != "" {
+		envs, err = m.readEnvs(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file '%s': %v", envFile, err)
+		}
+	}
+
+	rootDir, err := filepath.Abs(m.rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(m.detaPath, devDir)
+	err = os.MkdirAll(dir, dirPermMode)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := filepath.Abs(filepath.Join(dir, devEntryFiles[r.Name]))
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(entry, []byte(devEntries[r.Name]), filePermMode)
+	if err != nil {
+		return nil, err
+	}
+	includeDir, err := m.linkDevIncludes(filepath.Join(dir, devIncludeDir))
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(devCommands[r.Name], entry)
+	cmd.Dir = rootDir
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("DETA_DEV_ROOT=%s", rootDir),
+		fmt.Sprintf("DETA_DEV_PORT=%d", port),
+		fmt.Sprintf("DETA_DEV_RUN_PATH=%s", DevRunPath),
+	)
+	if includeDir != "" {
+		for _, k := range []string{"PYTHONPATH", "NODE_PATH"} {
+			v := includeDir
+			if prev := os.Getenv(k); prev != "" {
+				v = strings.Join([]string{includeDir, prev}, string(os.PathListSeparator))
+			}
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+	for k, v := range envs {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+	return cmd, nil
+}
+
+// linkDevIncludes links the include dirs at their prefixes under dir
+// dir is recreated on every call, returns an empty path if there are no include dirs
+func (m *Manager) linkDevIncludes(dir string) (string, error) {
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return "", err
+	}
+	includes, err := m.getIncludes()
+	if err != nil {
+		return "", err
+	}
+	if len(includes) == 0 {
+		return "", nil
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, inc := range includes {
+		link := filepath.Join(dir, filepath.FromSlash(inc.prefix))
+		err = os.MkdirAll(filepath.Dir(link), dirPermMode)
+		if err != nil {
+			return "", err
+		}
+		err = os.Symlink(inc.dir, link)
+		if err != nil {
+			return "", fmt.Errorf("failed to link include dir '%s': %v", inc.dir, err)
+		}
+	}
+	return dir, nil
+}
+
+// IsDevChange checks if a change of the file at path should reload the micro served locally
+// only changes of files in the root dir or include dirs deployed with the micro reload the micro
+// and changes of the env file relative to the root dir, even if not deployed
+// path must have symlinks resolved like the paths of file events
+func (m *Manager) IsDevChange(path, envFile string) (bool, error) {
+	r, err := m.GetRuntime()
+	if err != nil {
+		return false, err
+	}
+	rootDir, err := resolvePath(m.rootDir)
+	if err != nil {
+		return false, err
+	}
+	if envFile != "" {
+		envPath, err := resolvePath(filepath.Join(rootDir, envFile))
+		if err != nil {
+			return false, err
+		}
+		if path == envPath {
+			return true, nil
+		}
+	}
+
+	rel, ok := relPath(rootDir, path)
+	if !ok {
+		includes, err := m.getIncludes()
+		if err != nil {
+			return false, err
+		}
+		for _, inc := range includes {
+			incDir, err := resolvePath(inc.dir)
+			if err != nil {
+				return false, err
+			}
+			if incRel, inInclude := relPath(incDir, path); inInclude {
+				rel, ok = filepath.Join(filepath.FromSlash(inc.prefix), incRel), true
+				break
+			}
+		}
+	}
+	if !ok {
+		return false, nil
+	}
+	skip, err := m.isSkippedPath(filepath.ToSlash(rel), r.Name)
+	if err != nil {
+		return false, err
+	}
+	return !skip, nil
+}
+
+// resolvePath gets the absolute path with symlinks resolved
+// the path is only made absolute if it does not exist
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return abs, nil
+		}
+		return "", err
+	}
+	return resolved, nil
+}
+
+// relPath gets path relative to dir, false if path is not in dir
+func relPath(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
@@ -0,0 +1,167 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDevCommand(t *testing.T) {
+	m := newTestManager(t, "dev", map[string]string{
+		"main.py": "app = None",
+		".env":    "KEY=value\n# comment\nQUOTED=\"quoted value\"\n",
+	})
+
+	cmd, err := m.DevCommand(".env", 4200)
+	assert.NilError(t, err)
+
+	rootDir, err := filepath.Abs(m.rootDir)
+	assert.NilError(t, err)
+	entry := filepath.Join(rootDir, detaDir, devDir, devEntryFiles[Python])
+	assert.DeepEqual(t, []string{"python3", entry}, cmd.Args)
+	assert.Equal(t, rootDir, cmd.Dir)
+	assertTestFile(t, entry, pythonDevEntry)
+
+	envs := cmd.Env[len(cmd.Env)-5:]
+	assert.Assert(t, contains(envs, "DETA_DEV_ROOT="+rootDir))
+	assert.Assert(t, contains(envs, "DETA_DEV_PORT=4200"))
+	assert.Assert(t, contains(envs, "DETA_DEV_RUN_PATH="+DevRunPath))
+	assert.Assert(t, contains(envs, "KEY=value"))
+	assert.Assert(t, contains(envs, "QUOTED=quoted value"))
+
+	_, err = m.DevCommand("missing.env", 4200)
+	assert.ErrorContains(t, err, "failed to read env file 'missing.env'")
+}
+
+func TestDevCommandIncludes(t *testing.T) {
+	newTestManager(t, "dev_include_shared", map[string]string{
+		"utils/helpers.py": "x = 1",
+	})
+	m := newTestManager(t, "dev_include", map[string]string{
+		"main.py":   "from shared.utils import helpers",
+		"deta.yaml": "include:\n  - path: ../dev_include_shared/utils\n    prefix: shared/utils\n",
+	})
+
+	// existing import paths are kept after the include dir
+	prevPythonPath, prevNodePath := os.Getenv("PYTHONPATH"), os.Getenv("NODE_PATH")
+	defer func() {
+		os.Setenv("PYTHONPATH", prevPythonPath)
+		os.Setenv("NODE_PATH", prevNodePath)
+	}()
+	os.Setenv("PYTHONPATH", "lib")
+	os.Setenv("NODE_PATH", "")
+
+	cmd, err := m.DevCommand("", 4200)
+	assert.NilError(t, err)
+
+	// the include dirs are linked at their prefixes in a dir on the import paths
+	includeDir, err := filepath.Abs(filepath.Join(m.detaPath, devDir, devIncludeDir))
+	assert.NilError(t, err)
+	assertTestFile(t, filepath.Join(includeDir, "shared", "utils", "helpers.py"), "x = 1")
+	assert.Assert(t, contains(cmd.Env, "PYTHONPATH="+includeDir+string(os.PathListSeparator)+"lib"))
+	assert.Assert(t, contains(cmd.Env, "NODE_PATH="+includeDir))
+
+	// the links are recreated when the includes change
+	writeTestFile(t, testLocalPath(t, m, "deta.yaml"), "include:\n  - path: ../dev_include_shared/utils\n")
+	m.manifestRead, m.includesRead = false, false
+	_, err = m.DevCommand("", 4200)
+	assert.NilError(t, err)
+	assertTestFile(t, filepath.Join(includeDir, "utils", "helpers.py"), "x = 1")
+	_, err = os.Lstat(filepath.Join(includeDir, "shared"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestIsDevChange(t *testing.T) {
+	m := newTestManager(t, "dev_change", map[string]string{
+		"index.js":          "module.exports = app;",
+		"lib/utils.js":      "",
+		".detaignore":       "*.log\n",
+		"node_modules/a.js": "",
+		".env":              "KEY=value",
+		".env.local":        "KEY=local",
+	})
+	rootDir, err := filepath.Abs(m.rootDir)
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		path     string
+		isChange bool
+	}{
+		{filepath.Join(rootDir, "index.js"), true},
+		{filepath.Join(rootDir, "lib", "utils.js"), true},
+		{filepath.Join(rootDir, "debug.log"), false},
+		{filepath.Join(rootDir, "node_modules", "a.js"), false},
+		{filepath.Join(rootDir, detaDir, devDir, devEntryFiles[Node]), false},
+		{filepath.Join(filepath.Dir(rootDir), "other", "index.js"), false},
+		// the env file reloads the micro even if skipped
+		{filepath.Join(rootDir, ".env.local"), true},
+		{filepath.Join(rootDir, ".env"), false},
+	}
+	for _, tc := range testCases {
+		isChange, err := m.IsDevChange(tc.path, ".env.local")
+		assert.NilError(t, err, tc.path)
+		assert.Equal(t, tc.isChange, isChange, tc.path)
+	}
+}
+
+func TestIsDevChangeIncludes(t *testing.T) {
+	shared := newTestManager(t, "dev_change_include_shared", map[string]string{
+		"utils/helpers.js": "",
+		"utils/debug.log":  "",
+	})
+	m := newTestManager(t, "dev_change_include", map[string]string{
+		"index.js":    "module.exports = app;",
+		".detaignore": "*.log\n",
+		"deta.yaml":   "include:\n  - path: ../dev_change_include_shared/utils\n",
+	})
+	sharedDir, err := filepath.Abs(shared.rootDir)
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		path     string
+		isChange bool
+	}{
+		{filepath.Join(sharedDir, "utils", "helpers.js"), true},
+		{filepath.Join(sharedDir, "utils", "debug.log"), false},
+		{filepath.Join(sharedDir, "other.js"), false},
+	}
+	for _, tc := range testCases {
+		isChange, err := m.IsDevChange(tc.path, "")
+		assert.NilError(t, err, tc.path)
+		assert.Equal(t, tc.isChange, isChange, tc.path)
+	}
+}
+
+func TestIsDevChangeSymlinks(t *testing.T) {
+	m := newTestManager(t, "dev_change_symlinks", map[string]string{
+		"index.js":   "module.exports = app;",
+		".env.local": "KEY=local",
+	})
+	writeTestFile(t, m.progInfoPath, `{"id": "prog-id", "runtime": "nodejs14.x"}`)
+	realDir, err := filepath.EvalSymlinks(m.rootDir)
+	assert.NilError(t, err)
+	realDir, err = filepath.Abs(realDir)
+	assert.NilError(t, err)
+
+	// the root dir is used through a symlink, file events report the resolved paths
+	link := filepath.Join(filepath.Dir(m.rootDir), "dev_change_symlinks_link")
+	assert.NilError(t, os.Symlink(realDir, link))
+	m.rootDir = link
+	m.detaPath = filepath.Join(link, detaDir)
+
+	testCases := []struct {
+		path     string
+		isChange bool
+	}{
+		{filepath.Join(realDir, "index.js"), true},
+		{filepath.Join(realDir, ".env.local"), true},
+		{filepath.Join(realDir, detaDir, devDir, devEntryFiles[Node]), false},
+	}
+	for _, tc := range testCases {
+		isChange, err := m.IsDevChange(tc.path, ".env.local")
+		assert.NilError(t, err, tc.path)
+		assert.Equal(t, tc.isChange, isChange, tc.path)
+	}
+}
@@ -0,0 +1,430 @@
+package runtime
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// number of unchanged lines shown around changes in a unified diff
+	diffContext = 3
+
+	// DiffAdded file present in the root dir but not deployed
+	DiffAdded = "added"
+	// DiffModified file deployed with different contents than in the root dir
+	DiffModified = "modified"
+	// DiffDeleted file deployed but not present in the root dir
+	DiffDeleted = "deleted"
+
+	// path shown in unified diffs for a missing file
+	devNull = "/dev/null"
+
+	// maximum number of removed and added lines of the shortest edit script of a diff
+	// the trace of the myers algorithm grows with the square of the edits
+	maxDiffEdits = 2000
+)
+
+// FileDiff difference of a file between the deployed code and the root dir
+type FileDiff struct {
+	Path      string
+	Status    string
+	Binary    bool
+	Additions int
+	Deletions int
+	// unified diff of the file, deployed code as the old and root dir as the new version
+	Patch string
+}
+
+// diff operation on a line
+type lineOp struct {
+	kind byte // ' ' for unchanged, '-' for removed, '+' for added lines
+	line string
+	// index of the line in the old and new version before the operation
+	oldIndex int
+	newIndex int
+}
+
+// splitLines splits contents into lines keeping the line endings
+func splitLines(contents []byte) []string {
+	if len(contents) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(contents), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines gets an edit script from a to b
+// the script is the shortest with the myers algorithm unless a and b differ in more than maxDiffEdits lines
+// then all differing lines between the common prefix and suffix are replaced
+func diffLines(a, b []string) []lineOp {
+	// lines of the common prefix and suffix are unchanged
+	n, m := len(a), len(b)
+	prefix := 0
+	for prefix < n && prefix < m && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && suffix < m-prefix && a[n-1-suffix] == b[m-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]lineOp, 0, prefix+suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, lineOp{kind: ' ', line: a[i], oldIndex: i, newIndex: i})
+	}
+
+	middle, ok := myersDiff(a[prefix:n-suffix], b[prefix:m-suffix], prefix)
+	if !ok {
+		middle = replaceLines(a[prefix:n-suffix], b[prefix:m-suffix], prefix)
+	}
+	ops = append(ops, middle...)
+
+	for i := suffix; i > 0; i-- {
+		ops = append(ops, lineOp{kind: ' ', line: a[n-i], oldIndex: n - i, newIndex: m - i})
+	}
+	return ops
+}
+
+// myersDiff gets the shortest edit script from a to b with the myers algorithm
+// start is the index of the first lines of a and b in the whole old and new versions
+// returns false if a and b differ in more than maxDiffEdits lines
+func myersDiff(a, b []string, start int) ([]lineOp, bool) {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace of the diagonals -d to d of v before each step d, used to backtrack the edit script
+	// only the band of diagonals reachable in d steps is kept, so the trace grows with d² instead of (n+m)·d
+	var trace [][]int
+search:
+	for d := 0; d <= max; d++ {
+		if d > maxDiffEdits {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var ops []lineOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		// diagonal k of step d is at index k+d of its band
+		band := trace[d]
+		k := x - y
+		prevK := 0
+		if d > 0 {
+			if k == -d || (k != d && band[k-1+d] < band[k+1+d]) {
+				prevK = k + 1
+			} else {
+				prevK = k - 1
+			}
+		}
+		prevX := band[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, lineOp{kind: ' ', line: a[x], oldIndex: start + x, newIndex: start + y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, lineOp{kind: '+', line: b[y], oldIndex: start + x, newIndex: start + y})
+			} else {
+				x--
+				ops = append(ops, lineOp{kind: '-', line: a[x], oldIndex: start + x, newIndex: start + y})
+			}
+		}
+	}
+
+	// ops were added backwards
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, true
+}
+
+// replaceLines gets an edit script removing all lines of a and adding all lines of b
+// start is the index of the first lines of a and b in the whole old and new versions
+func replaceLines(a, b []string, start int) []lineOp {
+	ops := make([]lineOp, 0, len(a)+len(b))
+	for i, line := range a {
+		ops = append(ops, lineOp{kind: '-', line: line, oldIndex: start + i, newIndex: start})
+	}
+	for i, line := range b {
+		ops = append(ops, lineOp{kind: '+', line: line, oldIndex: start + len(a), newIndex: start + i})
+	}
+	return ops
+}
+
+// hunkRange formats the range of a hunk header
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	start++
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// unifiedDiff gets the hunks of a unified diff from old to new contents
+// returns an empty diff if the contents are the same
+func unifiedDiff(old, new []byte) (diff string, additions int, deletions int) {
+	ops := diffLines(splitLines(old), splitLines(new))
+
+	var changes []int
+	for i, op := range ops {
+		switch op.kind {
+		case '+':
+			additions++
+			changes = append(changes, i)
+		case '-':
+			deletions++
+			changes = append(changes, i)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(changes); {
+		// group changes with overlapping context into a single hunk
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext {
+			j++
+		}
+		start := changes[i] - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + diffContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		var oldCount, newCount int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(ops[start].oldIndex, oldCount), hunkRange(ops[start].newIndex, newCount))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = j + 1
+	}
+	return b.String(), additions, deletions
+}
+
+// newFileDiff gets the diff of a file from the deployed to the local contents, nil if the contents are the same
+func newFileDiff(path string, deployed, local []byte, status string) *FileDiff {
+	if status == DiffModified && bytes.Equal(deployed, local) {
+		return nil
+	}
+
+	oldName, newName := "a/"+path, "b/"+path
+	switch status {
+	case DiffAdded:
+		oldName = devNull
+	case DiffDeleted:
+		newName = devNull
+	}
+
+	d := &FileDiff{
+		Path:   path,
+		Status: status,
+		Binary: isBinary(deployed) || isBinary(local),
+	}
+	if d.Binary {
+		d.Patch = fmt.Sprintf("Binary files %s and %s differ\n", oldName, newName)
+		return d
+	}
+
+	hunks, additions, deletions := unifiedDiff(deployed, local)
+	d.Additions = additions
+	d.Deletions = deletions
+	d.Patch = fmt.Sprintf("--- %s\n+++ %s\n%s", oldName, newName, hunks)
+	return d
+}
+
+// isSkippedPath checks if a slash separated file path or any of its parent dirs should be skipped
+func (m *Manager) isSkippedPath(path string, runtime string) (bool, error) {
+	parts := strings.Split(path, "/")
+	for i := 1; i <= len(parts); i++ {
+		skip, err := m.shouldSkip(filepath.FromSlash(strings.Join(parts[:i], "/")), i < len(parts), runtime)
+		if err != nil {
+			return false, err
+		}
+		if skip {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// readDeployed reads the files of the deployed code in zipFile
+// lib entry files and files skipped on deploy are not read
+func (m *Manager) readDeployed(zipFile []byte, runtime string) (map[string][]byte, error) {
+	files, err := readZip(zipFile, libEntryFiles[runtime])
+	if err != nil {
+		return nil, err
+	}
+	for path := range files {
+		skip, err := m.isSkippedPath(path, runtime)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			delete(files, path)
+		}
+	}
+	return files, nil
+}
+
+// deployedChecksums gets a map of deployed files to checksums
+func deployedChecksums(files map[string][]byte) map[string]string {
+	checksums := make(map[string]string, len(files))
+	for path, contents := range files {
+		checksums[path] = fmt.Sprintf("%x", sha256.Sum256(contents))
+	}
+	return checksums
+}
+
+// Drift gets the deployed files that changed since the last deploy or pull from the root dir
+// returns nil if the deployed code in zipFile is the same as last deployed or pulled
+func (m *Manager) Drift(zipFile []byte) ([]string, error) {
+	r, err := m.GetRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := m.getStoredState()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		s = &state{
+			Files: make(stateMap),
+		}
+	}
+
+	// files of the state are compared even if skipped by the current ignore rules
+	// as they were deployed before being ignored
+	files, err := readZip(zipFile, libEntryFiles[r.Name])
+	if err != nil {
+		return nil, err
+	}
+	for path := range files {
+		if _, ok := s.Files[path]; ok {
+			continue
+		}
+		skip, err := m.isSkippedPath(path, r.Name)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			delete(files, path)
+		}
+	}
+	deployed := deployedChecksums(files)
+	if fingerprint(deployed) == s.fingerprint() {
+		return nil, nil
+	}
+
+	var drift []string
+	for path, checksum := range deployed {
+		if f, ok := s.Files[path]; !ok || f.Checksum != checksum {
+			drift = append(drift, path)
+		}
+	}
+	for path := range s.Files {
+		if _, ok := deployed[path]; !ok {
+			drift = append(drift, path)
+		}
+	}
+	sort.Strings(drift)
+	return drift, nil
+}
+
+// Diff gets the differences between the deployed code in zipFile and the files in the root dir
+// files skipped on deploy are not compared, diffs are sorted by path
+func (m *Manager) Diff(zipFile []byte) ([]*FileDiff, error) {
+	r, err := m.GetRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	deployed, err := m.readDeployed(zipFile, r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []*FileDiff
+	err = m.walk(r.Name, func(path string, info os.FileInfo) error {
+		localPath, err := m.localPath(path)
+		if err != nil {
+			return err
+		}
+		local, err := m.readFile(localPath)
+		if err != nil {
+			return err
+		}
+		status := DiffModified
+		contents, ok := deployed[path]
+		if !ok {
+			status = DiffAdded
+		}
+		delete(deployed, path)
+
+		if d := newFileDiff(path, contents, local, status); d != nil {
+			diffs = append(diffs, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for path, contents := range deployed {
+		diffs = append(diffs, newFileDiff(path, contents, nil, DiffDeleted))
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs, nil
+}
@@ -0,0 +1,236 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	testCases := []struct {
+		old       string
+		new       string
+		diff      string
+		additions int
+		deletions int
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", "", 0, 0},
+		{"", "a\nb\n", "@@ -0,0 +1,2 @@\n+a\n+b\n", 2, 0},
+		{"a\nb\n", "", "@@ -1,2 +0,0 @@\n-a\n-b\n", 0, 2},
+		{"a\nb\nc\n", "a\nx\nc\n", "@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n", 1, 1},
+		{"a\n", "a", "@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n", 1, 1},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			"@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -9,4 +10,3 @@\n 9\n 10\n 11\n-12\n",
+			1, 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		msg := fmt.Sprintf("old: %q, new: %q", tc.old, tc.new)
+		diff, additions, deletions := unifiedDiff([]byte(tc.old), []byte(tc.new))
+		assert.Equal(t, tc.diff, diff, msg)
+		assert.Equal(t, tc.additions, additions, msg)
+		assert.Equal(t, tc.deletions, deletions, msg)
+	}
+}
+
+// applyOps checks that ops turn a into b with valid indexes
+func applyOps(t *testing.T, ops []lineOp, a, b []string) {
+	var old, new []string
+	for _, op := range ops {
+		assert.Equal(t, len(old), op.oldIndex)
+		assert.Equal(t, len(new), op.newIndex)
+		if op.kind != '+' {
+			old = append(old, op.line)
+		}
+		if op.kind != '-' {
+			new = append(new, op.line)
+		}
+	}
+	assert.DeepEqual(t, a, old)
+	assert.DeepEqual(t, b, new)
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	n := 50000
+	a, b := make([]string, n), make([]string, n)
+	for i := 0; i < n; i++ {
+		a[i] = fmt.Sprintf("old %d\n", i)
+		b[i] = fmt.Sprintf("new %d\n", i)
+	}
+
+	// completely different files are replaced without tracing the shortest edit script
+	ops := diffLines(a, b)
+	applyOps(t, ops, a, b)
+	_, additions, deletions := unifiedDiff([]byte(strings.Join(a, "")), []byte(strings.Join(b, "")))
+	assert.Equal(t, n, additions)
+	assert.Equal(t, n, deletions)
+
+	// a few changes in large files get the shortest edit script
+	c := append([]string(nil), a...)
+	c[0] = "changed first\n"
+	c[n/2] = "changed middle\n"
+	c = append(c[:n-10], c[n-9:]...)
+	c = append(c, "added last\n")
+	ops = diffLines(a, c)
+	applyOps(t, ops, a, c)
+	var changes int
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changes++
+		}
+	}
+	assert.Equal(t, 6, changes)
+}
+
+func TestDiff(t *testing.T) {
+	m := newTestManager(t, "diff", map[string]string{
+		"main.py":      "a\nb\n",
+		"new.py":       "x\n",
+		"same.py":      "same\n",
+		ignoreFile:     "build/\n",
+		"build/out.py": "ignored\n",
+	})
+
+	zipFile := newTestZip(t, map[string]string{
+		"main.py":      "a\n",
+		"same.py":      "same\n",
+		"old.py":       "old\n",
+		"_entry.py":    "lib\n",
+		"build/out.py": "deployed\n",
+	})
+	diffs, err := m.Diff(zipFile)
+	assert.NilError(t, err)
+
+	var got []string
+	for _, d := range diffs {
+		got = append(got, fmt.Sprintf("%s %s +%d -%d", d.Status, d.Path, d.Additions, d.Deletions))
+	}
+	assert.DeepEqual(t, []string{
+		"added .detaignore +1 -0",
+		"modified main.py +1 -0",
+		"added new.py +1 -0",
+		"deleted old.py +0 -1",
+	}, got)
+}
+
+func TestDrift(t *testing.T) {
+	m := newTestManager(t, "drift", map[string]string{
+		"main.py":  "a\n",
+		"utils.py": "b\n",
+	})
+
+	// nothing deployed
+	drift, err := m.Drift(newTestZip(t, map[string]string{"main.py": "a\n"}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"main.py"}, drift)
+
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.NilError(t, m.UpdateState(sc))
+
+	deployed := map[string]string{
+		"main.py":   "a\n",
+		"utils.py":  "b\n",
+		"_entry.py": "lib\n",
+	}
+	drift, err = m.Drift(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	assert.Assert(t, drift == nil)
+
+	// local changes are not drift
+	writeTestFile(t, testLocalPath(t, m, "main.py"), "local\n")
+	drift, err = m.Drift(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	assert.Assert(t, drift == nil)
+
+	// deployed from somewhere else
+	deployed["utils.py"] = "c\n"
+	deployed["other.py"] = "d\n"
+	drift, err = m.Drift(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"other.py", "utils.py"}, drift)
+
+	// pulling updates the fingerprint
+	_, err = m.Pull(newTestZip(t, deployed), true)
+	assert.NilError(t, err)
+	drift, err = m.Drift(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	assert.Assert(t, drift == nil)
+
+	// storing the state of the local files updates the fingerprint
+	assert.NilError(t, m.StoreState())
+	drift, err = m.Drift(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"main.py"}, drift)
+}
+
+func TestOverwriteDrift(t *testing.T) {
+	m := newTestManager(t, "overwrite_drift", map[string]string{
+		"main.py":  "a\n",
+		"utils.py": "b\n",
+	})
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.NilError(t, m.UpdateState(sc))
+
+	// changed and added only in the deployed code
+	deployed := map[string]string{
+		"main.py":   "a\n",
+		"utils.py":  "c\n",
+		"other.py":  "d\n",
+		"_entry.py": "lib\n",
+	}
+	drift, err := m.Drift(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"other.py", "utils.py"}, drift)
+
+	// storing the deployed code as the state deploys the local files over the drift
+	_, err = m.Link(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"utils.py"}, sortedKeys(changedPaths(sc)))
+	assert.DeepEqual(t, []string{"other.py"}, sc.Deletions)
+	assert.NilError(t, m.UpdateState(sc))
+
+	// the deployed code is the same as the local files after the deploy
+	drift, err = m.Drift(newTestZip(t, map[string]string{
+		"main.py":   "a\n",
+		"utils.py":  "b\n",
+		"_entry.py": "lib\n",
+	}))
+	assert.NilError(t, err)
+	assert.Assert(t, drift == nil)
+}
+
+func TestDriftIgnoredFiles(t *testing.T) {
+	m := newTestManager(t, "drift_ignored", map[string]string{
+		"main.py":  "a\n",
+		"notes.md": "b\n",
+	})
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.NilError(t, m.UpdateState(sc))
+
+	// files deployed before being ignored are not drift
+	writeTestFile(t, testLocalPath(t, m, ignoreFile), "*.md\n")
+	m.ignoreRules = make(map[string][]Pattern)
+	deployed := map[string]string{
+		"main.py":  "a\n",
+		"notes.md": "b\n",
+	}
+	drift, err := m.Drift(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	assert.Assert(t, drift == nil)
+
+	// changes of ignored files in the state are still drift
+	deployed["notes.md"] = "c\n"
+	drift, err = m.Drift(newTestZip(t, deployed))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"notes.md"}, drift)
+}
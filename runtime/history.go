@@ -0,0 +1,287 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// max number of snapshots kept in the history
+	maxSnapshots = 20
+)
+
+var (
+	// local paths to store the deployment history
+	historyDir  = "history"
+	objectsDir  = "objects"
+	snapshotExt = ".json"
+
+	// ErrNoHistory no deployment history present
+	ErrNoHistory = errors.New("no deployment history present")
+)
+
+// Snapshot the set of deployed files of a deployment
+type Snapshot struct {
+	ID         string            `json:"id"`
+	DeployedAt time.Time         `json:"deployed_at"`
+	CLIVersion string            `json:"cli_version"`
+	Files      map[string]string `json:"files"` // map of files to checksum
+}
+
+// unmarshals data into a Snapshot
+func snapshotFromBytes(data []byte) (*Snapshot, error) {
+	var s Snapshot
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// path to the history dir
+func (m *Manager) historyPath() string {
+	return filepath.Join(m.profilePath(), historyDir)
+}
+
+// path to the contents of a file stored in the history with checksum
+func (m *Manager) objectPath(checksum string) string {
+	return filepath.Join(m.historyPath(), objectsDir, checksum)
+}
+
+// storeObject stores the contents of a file in the root dir in the history by checksum
+// stored is false if the file in the root dir does not have the checksum anymore
+func (m *Manager) storeObject(path, checksum string) (stored bool, err error) {
+	objectPath := m.objectPath(checksum)
+	if _, err := os.Stat(objectPath); err == nil {
+		return true, nil
+	}
+
+	localPath, err := m.localPath(path)
+	if err != nil {
+		return false, err
+	}
+	contents, err := m.readFile(localPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if fmt.Sprintf("%x", sha256.Sum256(contents)) != checksum {
+		return false, nil
+	}
+	return true, ioutil.WriteFile(objectPath, contents, filePermMode)
+}
+
+// StoreObjects stores the contents of the changes read with ReadContents in the history by checksum
+// used to store the contents as deployed, files may change in the root dir before the snapshot is stored
+func (m *Manager) StoreObjects(sc *StateChanges) error {
+	err := os.MkdirAll(filepath.Join(m.historyPath(), objectsDir), dirPermMode)
+	if err != nil {
+		return err
+	}
+	for path, f := range sc.Files {
+		var contents []byte
+		if encoded, ok := sc.BinaryFiles[path]; ok {
+			contents, err = base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return err
+			}
+		} else if text, ok := sc.Changes[path]; ok {
+			contents = []byte(text)
+		} else {
+			continue
+		}
+		objectPath := m.objectPath(f.Checksum)
+		if _, err := os.Stat(objectPath); err == nil {
+			continue
+		}
+		err = ioutil.WriteFile(objectPath, contents, filePermMode)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StoreSnapshot stores the files of the stored state as a snapshot in the deployment history
+// contents of files are stored by checksum and read from the root dir if not already stored with StoreObjects
+func (m *Manager) StoreSnapshot() (*Snapshot, error) {
+	s, err := m.getStoredState()
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Join(m.historyPath(), objectsDir), dirPermMode)
+	if err != nil {
+		return nil, err
+	}
+
+	deployedAt := s.DeployedAt
+	if deployedAt.IsZero() {
+		deployedAt = time.Now().UTC()
+	}
+
+	snapshot := &Snapshot{
+		ID:         strconv.FormatInt(deployedAt.UnixNano(), 10),
+		DeployedAt: deployedAt,
+		CLIVersion: m.cliVersion,
+		Files:      make(map[string]string, len(s.Files)),
+	}
+	for path, f := range s.Files {
+		stored, err := m.storeObject(path, f.Checksum)
+		if err != nil {
+			return nil, err
+		}
+		if !stored {
+			return nil, fmt.Errorf("failed to store '%s' in history, file changed since deployed", path)
+		}
+		snapshot.Files[path] = f.Checksum
+	}
+
+	marshalled, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(filepath.Join(m.historyPath(), snapshot.ID+snapshotExt), marshalled, filePermMode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.pruneHistory()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// GetHistory gets the snapshots of the deployment history, latest first
+func (m *Manager) GetHistory() ([]*Snapshot, error) {
+	entries, err := ioutil.ReadDir(m.historyPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []*Snapshot
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), snapshotExt) {
+			continue
+		}
+		contents, err := m.readFile(filepath.Join(m.historyPath(), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		s, err := snapshotFromBytes(contents)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].DeployedAt.After(snapshots[j].DeployedAt)
+	})
+	return snapshots, nil
+}
+
+// pruneHistory removes snapshots older than the last maxSnapshots
+// and the contents of files not used by any remaining snapshot
+func (m *Manager) pruneHistory() error {
+	snapshots, err := m.GetHistory()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) <= maxSnapshots {
+		return nil
+	}
+
+	for _, s := range snapshots[maxSnapshots:] {
+		err = os.Remove(filepath.Join(m.historyPath(), s.ID+snapshotExt))
+		if err != nil {
+			return err
+		}
+	}
+
+	used := make(map[string]struct{})
+	for _, s := range snapshots[:maxSnapshots] {
+		for _, checksum := range s.Files {
+			used[checksum] = struct{}{}
+		}
+	}
+	objects, err := ioutil.ReadDir(filepath.Join(m.historyPath(), objectsDir))
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if _, ok := used[o.Name()]; !ok {
+			err = os.Remove(m.objectPath(o.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetSnapshotChanges gets the changes from the stored state to the files of a snapshot
+// contents of the changes are read from the history with ReadContents
+func (m *Manager) GetSnapshotChanges(snapshot *Snapshot) (*StateChanges, error) {
+	s, err := m.getStoredState()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		s = &state{}
+	}
+
+	sc := newStateChanges()
+	for path, checksum := range snapshot.Files {
+		stored, ok := s.Files[path]
+		if ok && stored.Checksum == checksum {
+			continue
+		}
+
+		f, err := m.hashFile(m.objectPath(checksum))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("contents of '%s' not found in history", path)
+			}
+			return nil, err
+		}
+		if f.Checksum != checksum {
+			return nil, fmt.Errorf("contents of '%s' in history are corrupted", path)
+		}
+		// the file in the root dir is not the deployed file
+		f.ModTime = 0
+
+		sc.Files[path] = f
+		if !ok {
+			sc.Additions = append(sc.Additions, path)
+		}
+	}
+
+	for path := range s.Files {
+		if _, ok := snapshot.Files[path]; !ok {
+			sc.Deletions = append(sc.Deletions, path)
+		}
+	}
+	sort.Strings(sc.Deletions)
+
+	if len(sc.Files) == 0 && len(sc.Deletions) == 0 {
+		return nil, nil
+	}
+	return sc, nil
+}
@@ -0,0 +1,124 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+// deployTestChanges gets and reads the changes and updates the state as if deployed
+func deployTestChanges(t *testing.T, m *Manager, sc *StateChanges) {
+	assert.NilError(t, m.ReadContents(sc))
+	assert.NilError(t, m.UpdateState(sc))
+	_, err := m.StoreSnapshot()
+	assert.NilError(t, err)
+}
+
+func TestSnapshotChanges(t *testing.T) {
+	m := newTestManager(t, "history", map[string]string{
+		"main.py":  "print('hello')",
+		"utils.py": "x = 1",
+	})
+
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	deployTestChanges(t, m, sc)
+
+	// snapshots are ordered by deployed at
+	time.Sleep(time.Millisecond)
+	writeTestFile(t, testLocalPath(t, m, "main.py"), "print('world')")
+	writeTestFile(t, testLocalPath(t, m, "new.py"), "y = 2")
+	assert.NilError(t, os.Remove(testLocalPath(t, m, "utils.py")))
+
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	deployTestChanges(t, m, sc)
+
+	snapshots, err := m.GetHistory()
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(snapshots))
+	assert.DeepEqual(t, []string{"main.py", "new.py"}, sortedKeys(snapshots[0].Files))
+
+	// no changes to the latest snapshot
+	sc, err = m.GetSnapshotChanges(snapshots[0])
+	assert.NilError(t, err)
+	assert.Assert(t, sc == nil)
+
+	sc, err = m.GetSnapshotChanges(snapshots[1])
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"utils.py"}, sc.Additions)
+	assert.DeepEqual(t, []string{"new.py"}, sc.Deletions)
+	assert.Equal(t, 2, len(sc.Files))
+
+	// contents are read from the history, not the root dir
+	assert.NilError(t, m.ReadContents(sc))
+	assert.Equal(t, "print('hello')", sc.Changes["main.py"])
+	assert.Equal(t, "x = 1", sc.Changes["utils.py"])
+	assert.Equal(t, int64(0), sc.Files["main.py"].ModTime)
+
+	// rolled back files are deployed again since the local files differ
+	assert.NilError(t, m.UpdateState(sc))
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.Assert(t, sc != nil)
+	_, ok := sc.Files["main.py"]
+	assert.Assert(t, ok)
+}
+
+func TestSnapshotFileChangedSinceDeployed(t *testing.T) {
+	m := newTestManager(t, "history_changed", map[string]string{
+		"main.py":  "print('hello')",
+		"logo.png": "\x89PNG\x00\x01",
+	})
+
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.NilError(t, m.ReadContents(sc))
+	assert.NilError(t, m.UpdateState(sc))
+	assert.NilError(t, m.StoreObjects(sc))
+
+	// files changed after the upload are stored as deployed
+	writeTestFile(t, testLocalPath(t, m, "main.py"), "print('changed')")
+	writeTestFile(t, testLocalPath(t, m, "logo.png"), "\x89PNG\x00\x02")
+	snapshot, err := m.StoreSnapshot()
+	assert.NilError(t, err)
+
+	assertTestFile(t, m.objectPath(snapshot.Files["main.py"]), "print('hello')")
+	assertTestFile(t, m.objectPath(snapshot.Files["logo.png"]), "\x89PNG\x00\x01")
+}
+
+func TestPruneHistory(t *testing.T) {
+	m := newTestManager(t, "prune_history", map[string]string{
+		"main.py": "0",
+	})
+
+	for i := 0; i < maxSnapshots+2; i++ {
+		writeTestFile(t, testLocalPath(t, m, "main.py"), string(rune('a'+i)))
+		sc, err := m.GetChanges()
+		assert.NilError(t, err)
+		deployTestChanges(t, m, sc)
+		time.Sleep(time.Millisecond)
+	}
+
+	snapshots, err := m.GetHistory()
+	assert.NilError(t, err)
+	assert.Equal(t, maxSnapshots, len(snapshots))
+
+	objects, err := ioutil.ReadDir(filepath.Join(m.historyPath(), objectsDir))
+	assert.NilError(t, err)
+	assert.Equal(t, maxSnapshots, len(objects))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,284 @@
+package runtime
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// syntax lines switch the syntax of the lines that follow in an ignore file
+	syntaxPrefix = "# syntax:"
+	globSyntax   = "glob"
+	regexSyntax  = "regex"
+
+	// a comment in an ignore file
+	commentChar = '#'
+)
+
+// matches checks if the pattern matches path, path is relative to the dir of the ignore file
+func (p *Pattern) matches(path string, isDir bool) bool {
+	if p.DirOnly && !isDir {
+		return false
+	}
+	return p.Value.MatchString(path)
+}
+
+// parseIgnoreFile parses the contents of an ignore file into patterns
+// patterns are returned in reverse order so that the last matching line takes precedence
+func parseIgnoreFile(contents []byte) ([]Pattern, error) {
+	lines, err := readLines(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	syntax := globSyntax
+	var patterns []Pattern
+	for _, line := range lines {
+		trimmed := strings.Trim(line, SPACE)
+		if strings.HasPrefix(trimmed, syntaxPrefix) {
+			switch strings.Trim(strings.TrimPrefix(trimmed, syntaxPrefix), SPACE) {
+			case regexSyntax:
+				syntax = regexSyntax
+			case globSyntax:
+				syntax = globSyntax
+			}
+			continue
+		}
+
+		var pattern *Pattern
+		if syntax == regexSyntax {
+			pattern = compileRegex(trimmed)
+		} else {
+			pattern = compileGlob(line)
+		}
+		if pattern == nil {
+			continue // ignore current line and continue
+		}
+		patterns = append([]Pattern{*pattern}, patterns...)
+	}
+	return patterns, nil
+}
+
+// compileRegex compiles a line of an ignore file in regex syntax
+// returns nil if the line is empty, a comment or not a valid regex
+func compileRegex(line string) *Pattern {
+	if len(line) == 0 || line[0] == commentChar {
+		return nil
+	}
+
+	skip := true
+	if line[0] == NEGATION {
+		skip = false
+		line = line[1:]
+		if len(line) == 0 {
+			return nil
+		}
+	}
+
+	value, err := regexp.CompilePOSIX(line)
+	if err != nil {
+		return nil
+	}
+	return &Pattern{
+		Value: value,
+		Skip:  skip,
+	}
+}
+
+// compileGlob compiles a line of an ignore file in gitignore syntax
+// returns nil if the line is empty, a comment or not a valid pattern
+func compileGlob(line string) *Pattern {
+	line = strings.TrimRight(line, SPACE)
+	if len(line) == 0 || line[0] == commentChar {
+		return nil
+	}
+
+	pattern := &Pattern{
+		Skip: true,
+	}
+	if line[0] == NEGATION {
+		pattern.Skip = false
+		line = line[1:]
+	}
+
+	// a trailing slash only matches directories
+	if strings.HasSuffix(line, "/") {
+		pattern.DirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if len(line) == 0 {
+		return nil
+	}
+
+	// a slash at the beginning or middle anchors the pattern to the dir of the ignore file
+	// otherwise the pattern matches at any depth
+	expr := globToRegexp(strings.TrimPrefix(line, "/"))
+	if !strings.Contains(line, "/") {
+		expr = "(?:.*/)?" + expr
+	}
+
+	value, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil
+	}
+	pattern.Value = value
+	return pattern
+}
+
+// globToRegexp converts a glob to a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**") {
+				// '**' is only special as a whole path segment
+				atStart := i == 0 || glob[i-1] == '/'
+				atEnd := i+2 == len(glob) || glob[i+2] == '/'
+				if atStart && atEnd {
+					if i+2 == len(glob) {
+						// trailing '**' matches everything inside
+						b.WriteString(".*")
+						i++
+					} else {
+						// leading or middle '**/' matches zero or more dirs
+						b.WriteString("(?:.*/)?")
+						i += 2
+					}
+					continue
+				}
+			}
+			b.WriteString("[^/]*")
+			// consecutive stars are the same as a single star
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			class, n := globClass(glob[i:])
+			if n == 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteString(class)
+			i += n - 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			} else {
+				b.WriteString(`\\`)
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// globClass converts a bracket expression at the start of glob to a regexp character class
+// returns the class and number of bytes consumed, 0 if the bracket is not closed
+func globClass(glob string) (string, int) {
+	var b strings.Builder
+	b.WriteString("[")
+
+	i := 1
+	if i < len(glob) && (glob[i] == NEGATION || glob[i] == '^') {
+		b.WriteString("^")
+		i++
+	}
+	// a ']' right after the opening bracket is part of the class
+	if i < len(glob) && glob[i] == ']' {
+		b.WriteString(`\]`)
+		i++
+	}
+	for ; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case ']':
+			b.WriteString("]")
+			return b.String(), i + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(`\` + string(glob[i]))
+			}
+		case '[', '^':
+			b.WriteString(`\` + string(c))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0
+}
+
+// ignorePatterns gets patterns of the ignore file in dir, dir is relative to the root dir
+// patterns of the root dir include the ignore rules of the manifest, patterns are read once and cached
+func (m *Manager) ignorePatterns(dir string) ([]Pattern, error) {
+	if patterns, ok := m.ignoreRules[dir]; ok {
+		return patterns, nil
+	}
+
+	localDir, err := m.localPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := m.readFile(filepath.Join(localDir, ignoreFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var patterns []Pattern
+	if len(contents) != 0 {
+		patterns, err = parseIgnoreFile(contents)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// ignore rules of the manifest are matched after the rules of the ignore file in the root dir
+	if dir == "" {
+		manifestPatterns, err := m.manifestPatterns()
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, manifestPatterns...)
+	}
+	m.ignoreRules[dir] = patterns
+	return patterns, nil
+}
+
+// isIgnored checks if a slash separated path relative to the root dir is matched by the ignore files
+// ignore files closer to the path take precedence, matched is false if no pattern matches
+func (m *Manager) isIgnored(p string, isDir bool) (skip bool, matched bool, err error) {
+	dir := path.Dir(p)
+	for {
+		if dir == "." {
+			dir = ""
+		}
+		patterns, err := m.ignorePatterns(dir)
+		if err != nil {
+			return false, false, err
+		}
+
+		rel := strings.TrimPrefix(p, dir+"/")
+		if dir == "" {
+			rel = p
+		}
+		for _, pattern := range patterns {
+			if pattern.matches(rel, isDir) {
+				return pattern.Skip, true, nil
+			}
+		}
+
+		if dir == "" {
+			return false, false, nil
+		}
+		dir = path.Dir(dir)
+	}
+}
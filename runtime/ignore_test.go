@@ -0,0 +1,143 @@
+package runtime
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCompileGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		matches bool
+	}{
+		{"*.log", "debug.log", false, true},
+		{"*.log", "logs/debug.log", false, true},
+		{"*.log", "debug.log.txt", false, false},
+		{"/build/", "build", true, true},
+		{"/build/", "build", false, false},
+		{"/build/", "src/build", true, false},
+		{"build/", "src/build", true, true},
+		{"docs/**/*.md", "docs/index.md", false, true},
+		{"docs/**/*.md", "docs/a/b/index.md", false, true},
+		{"docs/**/*.md", "src/docs/index.md", false, false},
+		{"**/fixtures", "fixtures", true, true},
+		{"**/fixtures", "tests/unit/fixtures", true, true},
+		{"tests/**", "tests/unit/test_main.py", false, true},
+		{"tests/**", "tests", true, false},
+		{"test_?.py", "test_a.py", false, true},
+		{"test_?.py", "test_ab.py", false, false},
+		{"file[0-9].txt", "file1.txt", false, true},
+		{"file[!0-9].txt", "file1.txt", false, false},
+		{"file[!0-9].txt", "filea.txt", false, true},
+		{"a/b", "a/b", false, true},
+		{"a/b", "c/a/b", false, false},
+		{"\\#notes", "#notes", false, true},
+		{"*", "a/b/c", false, true},
+		{"foo.py", "foo_py", false, false},
+	}
+
+	for _, tc := range testCases {
+		msg := fmt.Sprintf("pattern: %s, path: %s", tc.pattern, tc.path)
+		p := compileGlob(tc.pattern)
+		assert.Assert(t, p != nil, msg)
+		assert.Equal(t, tc.matches, p.matches(tc.path, tc.isDir), msg)
+	}
+}
+
+func TestParseIgnoreFile(t *testing.T) {
+	contents := []byte(`# comment
+
+*.log
+!keep.log
+build/
+# syntax: regex
+#|.* comments are skipped in regex syntax
+^tmp.*
+[invalid
+# syntax: glob
+!tmp_keep
+`)
+	patterns, err := parseIgnoreFile(contents)
+	assert.NilError(t, err)
+	assert.Equal(t, 5, len(patterns))
+
+	testCases := []struct {
+		path  string
+		isDir bool
+		skip  bool
+	}{
+		{"error.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"tmp_file", false, true},
+		{"tmp_keep", false, false},
+	}
+	for _, tc := range testCases {
+		var skip bool
+		for _, p := range patterns {
+			if p.matches(tc.path, tc.isDir) {
+				skip = p.Skip
+				break
+			}
+		}
+		assert.Equal(t, tc.skip, skip, fmt.Sprintf("path: %s", tc.path))
+	}
+}
+
+func TestShouldSkipNestedIgnoreFiles(t *testing.T) {
+	rootDir := filepath.Join("testdata", "tmp", "ignore")
+	files := map[string]string{
+		ignoreFile:                            "*.txt\nfixtures/\n",
+		filepath.Join("sub", ignoreFile):      "!keep.txt\n/local.py\n",
+		filepath.Join("sub", "keep.txt"):      "",
+		filepath.Join("sub", "other.txt"):     "",
+		filepath.Join("sub", "local.py"):      "",
+		filepath.Join("sub", "a", "local.py"): "",
+	}
+	for name, content := range files {
+		p := filepath.Join(rootDir, name)
+		err := os.MkdirAll(filepath.Dir(p), os.ModePerm)
+		if err != nil {
+			t.Fatalf("failed to create dir for %s: %v", p, err)
+		}
+		err = ioutil.WriteFile(p, []byte(content), filePermMode)
+		if err != nil {
+			t.Fatalf("failed to write file %s: %v", p, err)
+		}
+	}
+
+	m := &Manager{
+		rootDir:     rootDir,
+		skipPaths:   skipPaths,
+		ignoreRules: make(map[string][]Pattern),
+	}
+
+	testCases := []struct {
+		path  string
+		isDir bool
+		skip  bool
+	}{
+		{"main.py", false, false},
+		{"notes.txt", false, true},
+		{"sub/keep.txt", false, false},
+		{"sub/other.txt", false, true},
+		{"sub/local.py", false, true},
+		{"sub/a/local.py", false, false},
+		{"sub/fixtures", true, true},
+		{"sub/.detaignore", false, false},
+		{".env", false, true},
+		{"__pycache__", true, true},
+	}
+	for _, tc := range testCases {
+		skip, err := m.shouldSkip(filepath.FromSlash(tc.path), tc.isDir, Python)
+		assert.NilError(t, err)
+		assert.Equal(t, tc.skip, skip, fmt.Sprintf("path: %s", tc.path))
+	}
+}
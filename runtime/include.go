@@ -0,0 +1,132 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Include a dir outside the root dir deployed with the files of the root dir
+type Include struct {
+	Path   string `json:"path" yaml:"path"`                         // path to the dir, relative to the root dir
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"` // path of the dir in the deployed files, defaults to the name of the dir
+}
+
+// include an Include resolved to the dir on disk
+type include struct {
+	dir    string
+	prefix string // slash separated
+}
+
+// contains checks if a slash separated path relative to the root dir is in the include
+// returns the path relative to the include dir
+func (inc *include) contains(p string) (string, bool) {
+	if p == inc.prefix {
+		return "", true
+	}
+	if strings.HasPrefix(p, inc.prefix+"/") {
+		return strings.TrimPrefix(p, inc.prefix+"/"), true
+	}
+	return "", false
+}
+
+// includeOf gets the include of a slash separated path relative to the root dir
+// and the path relative to the include dir, nil if the path is not in an include
+func (m *Manager) includeOf(path string) (*include, string, error) {
+	includes, err := m.getIncludes()
+	if err != nil {
+		return nil, "", err
+	}
+	for _, inc := range includes {
+		if rel, ok := inc.contains(path); ok {
+			return inc, rel, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// getIncludes gets the includes of the manifest resolved to dirs on disk
+// includes are resolved once and cached
+func (m *Manager) getIncludes() ([]*include, error) {
+	if m.includesRead {
+		return m.includes, nil
+	}
+
+	mf, err := m.GetManifest()
+	if err != nil {
+		return nil, err
+	}
+	if mf == nil {
+		m.includesRead = true
+		return nil, nil
+	}
+
+	rootDir, err := filepath.Abs(m.rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var includes []*include
+	for _, i := range mf.Include {
+		if i.Path == "" {
+			return nil, fmt.Errorf("include without path in '%s'", mf.File())
+		}
+		dir := filepath.FromSlash(i.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(rootDir, dir)
+		}
+		dir = filepath.Clean(dir)
+
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include '%s': %v", i.Path, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("failed to include '%s': not a directory", i.Path)
+		}
+		if rel, err := filepath.Rel(rootDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("failed to include '%s': already in the root directory", i.Path)
+		}
+
+		prefix := i.Prefix
+		if prefix == "" {
+			prefix = filepath.Base(dir)
+		}
+		prefix = path.Clean(filepath.ToSlash(prefix))
+		if path.IsAbs(prefix) || prefix == "." || prefix == ".." || strings.HasPrefix(prefix, "../") {
+			return nil, fmt.Errorf("invalid prefix '%s' of include '%s', must be a relative path in the root directory", i.Prefix, i.Path)
+		}
+
+		for _, other := range includes {
+			_, inOther := other.contains(prefix)
+			_, containsOther := (&include{prefix: prefix}).contains(other.prefix)
+			if inOther || containsOther {
+				return nil, fmt.Errorf("prefix '%s' of include '%s' overlaps with prefix '%s'", prefix, i.Path, other.prefix)
+			}
+		}
+
+		includes = append(includes, &include{
+			dir:    dir,
+			prefix: prefix,
+		})
+	}
+
+	m.includes = includes
+	m.includesRead = true
+	return includes, nil
+}
+
+// IncludeDirs gets the dirs included with the files of the root dir
+func (m *Manager) IncludeDirs() ([]string, error) {
+	includes, err := m.getIncludes()
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(includes))
+	for _, inc := range includes {
+		dirs = append(dirs, inc.dir)
+	}
+	return dirs, nil
+}
@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestIncludes(t *testing.T) {
+	shared := newTestManager(t, "include_shared", map[string]string{
+		"utils/helpers.py":    "x = 1",
+		"utils/" + ignoreFile: "*.txt\n",
+		"utils/notes.txt":     "",
+		"other/other.py":      "y = 2",
+	})
+	m := newTestManager(t, "include", map[string]string{
+		"main.py": "print('hello')",
+		"deta.yaml": `include:
+  - path: ../include_shared/utils
+  - path: ../include_shared/other
+    prefix: lib/other
+`,
+	})
+
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{
+		"deta.yaml",
+		"lib/other/other.py",
+		"main.py",
+		"utils/.detaignore",
+		"utils/helpers.py",
+	}, sortedKeys(changedPaths(sc)))
+
+	// contents are read from the included dirs
+	assert.NilError(t, m.ReadContents(sc))
+	assert.Equal(t, "x = 1", sc.Changes["utils/helpers.py"])
+	assert.Equal(t, "y = 2", sc.Changes["lib/other/other.py"])
+	assert.NilError(t, m.UpdateState(sc))
+
+	// changes in included dirs are tracked in the state
+	writeTestFile(t, filepath.Join(shared.rootDir, "utils", "helpers.py"), "x = 2")
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"utils/helpers.py"}, sortedKeys(changedPaths(sc)))
+
+	dirs, err := m.IncludeDirs()
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(dirs))
+}
+
+func TestPullIncludes(t *testing.T) {
+	shared := newTestManager(t, "pull_include_shared", map[string]string{
+		"utils/helpers.py": "x = 1\n",
+	})
+	m := newTestManager(t, "pull_include", map[string]string{
+		"main.py":   "print('hello')\n",
+		"deta.yaml": "include:\n  - path: ../pull_include_shared/utils\n",
+	})
+	deployed := map[string]string{
+		"main.py":          "print('hello')\n",
+		"deta.yaml":        "include:\n  - path: ../pull_include_shared/utils\n",
+		"utils/helpers.py": "x = 1\n",
+	}
+	_, err := m.Pull(newTestZip(t, deployed), true)
+	assert.NilError(t, err)
+
+	// files of include dirs changed in the deployed code are not written
+	deployed["main.py"] = "print('world')\n"
+	deployed["utils/helpers.py"] = "x = 2\n"
+	deployed["utils/new.py"] = "y = 1\n"
+	result, err := m.Pull(newTestZip(t, deployed), true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"main.py"}, result.Updated)
+	assert.DeepEqual(t, []string{"utils/helpers.py", "utils/new.py"}, result.Included)
+	assertTestFile(t, filepath.Join(shared.rootDir, "utils", "helpers.py"), "x = 1\n")
+	_, err = os.Stat(filepath.Join(shared.rootDir, "utils", "new.py"))
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+
+	// a manifest with invalid includes fails to get local paths
+	writeTestFile(t, testLocalPath(t, m, "deta.yaml"), "include:\n  - path: ../missing\n")
+	m.manifestRead, m.includesRead = false, false
+	_, err = m.localPath("main.py")
+	assert.ErrorContains(t, err, "failed to include")
+}
+
+func TestIncludeErrors(t *testing.T) {
+	newTestManager(t, "include_errors_shared", map[string]string{
+		"utils/helpers.py": "",
+	})
+
+	testCases := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{"missing", "include:\n  - path: ../missing\n", "failed to include"},
+		{"inside", "include:\n  - path: sub\n", "already in the root directory"},
+		{"prefix", "include:\n  - path: ../include_errors_shared/utils\n    prefix: ../utils\n", "invalid prefix"},
+		{"overlap", "include:\n  - path: ../include_errors_shared/utils\n  - path: ../include_errors_shared\n    prefix: utils/sub\n", "overlaps"},
+		{"conflict", "include:\n  - path: ../include_errors_shared/utils\n", "conflicts"},
+	}
+	for _, tc := range testCases {
+		m := newTestManager(t, "include_errors_"+tc.name, map[string]string{
+			"main.py":        "",
+			"sub/a.py":       "",
+			"utils/local.py": "",
+			"deta.yaml":      tc.manifest,
+		})
+		_, err := m.GetChanges()
+		assert.ErrorContains(t, err, tc.err, tc.name)
+	}
+}
@@ -16,20 +16,61 @@ type EnvChanges struct {
 
 // ProgInfo program info
 type ProgInfo struct {
-	ID          string   `json:"id"`
-	Space       int64    `json:"space"`
-	Runtime     string   `json:"runtime"` // runtime version eg: nodejs12.x
-	RuntimeName string   `json:"-"`
-	Name        string   `json:"name"`
-	Path        string   `json:"path"`
-	Project     string   `json:"project"`
-	Account     string   `json:"account"`
-	Region      string   `json:"region"`
-	Deps        []string `json:"deps"`
-	Envs        []string `json:"envs"`
-	Public      bool     `json:"public"`
-	Visor       string   `json:"log_level"`
-	Cron        string   `json:"cron"`
+	ID          string      `json:"id"`
+	Space       int64       `json:"space"`
+	Runtime     string      `json:"runtime"` // runtime version eg: nodejs12.x
+	RuntimeName string      `json:"-"`
+	Name        string      `json:"name"`
+	Path        string      `json:"path"`
+	Project     string      `json:"project"`
+	Account     string      `json:"account"`
+	Region      string      `json:"region"`
+	Deps        []string    `json:"deps"`
+	Envs        []string    `json:"envs"`
+	Public      bool        `json:"public"`
+	Visor       string      `json:"log_level"`
+	Cron        string      `json:"cron"`
+	Schedules   []*Schedule `json:"schedules,omitempty"` // named schedules in addition to Cron
+}
+
+// Schedule named schedule of a program running an action
+type Schedule struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Action     string `json:"action,omitempty"`
+	Body       string `json:"body,omitempty"`
+}
+
+// GetSchedule gets the schedule with name, nil if the program has no schedule with name
+func (p *ProgInfo) GetSchedule(name string) *Schedule {
+	for _, s := range p.Schedules {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// SetSchedule adds the schedule or replaces the schedule with the same name
+func (p *ProgInfo) SetSchedule(schedule *Schedule) {
+	for i, s := range p.Schedules {
+		if s.Name == schedule.Name {
+			p.Schedules[i] = schedule
+			return
+		}
+	}
+	p.Schedules = append(p.Schedules, schedule)
+}
+
+// RemoveSchedule removes the schedule with name, returns false if the program has no schedule with name
+func (p *ProgInfo) RemoveSchedule(name string) bool {
+	for i, s := range p.Schedules {
+		if s.Name == name {
+			p.Schedules = append(p.Schedules[:i], p.Schedules[i+1:]...)
+			return true
+		}
+	}
+	return false
 }
 
 // unmarshals data into a ProgInfo
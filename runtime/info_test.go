@@ -0,0 +1,66 @@
+package runtime
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProgInfoSchedules(t *testing.T) {
+	p := &ProgInfo{}
+	p.SetSchedule(&Schedule{Name: "nightly", Expression: "0 3 * * ? *"})
+	p.SetSchedule(&Schedule{Name: "hourly", Expression: "1 hour", Action: "sync"})
+	assert.Equal(t, 2, len(p.Schedules))
+
+	// a schedule with the same name is replaced
+	p.SetSchedule(&Schedule{Name: "nightly", Expression: "0 4 * * ? *", Body: `{"dry":true}`})
+	assert.DeepEqual(t, []*Schedule{
+		{Name: "nightly", Expression: "0 4 * * ? *", Body: `{"dry":true}`},
+		{Name: "hourly", Expression: "1 hour", Action: "sync"},
+	}, p.Schedules)
+	assert.Equal(t, "sync", p.GetSchedule("hourly").Action)
+	assert.Assert(t, p.GetSchedule("weekly") == nil)
+
+	assert.Assert(t, p.RemoveSchedule("nightly"))
+	assert.Assert(t, !p.RemoveSchedule("nightly"))
+	assert.DeepEqual(t, []*Schedule{
+		{Name: "hourly", Expression: "1 hour", Action: "sync"},
+	}, p.Schedules)
+}
+
+func TestReadProgInfoSchedules(t *testing.T) {
+	m := newTestManager(t, "prog_info_schedules", map[string]string{
+		"main.py": "print('hello')",
+	})
+	writeTestFile(t, m.progInfoPath, `{
+		"id": "prog-id",
+		"runtime": "python3.9",
+		"cron": "1 minute",
+		"schedules": [
+			{"name": "nightly", "expression": "0 3 * * ? *", "action": "cleanup", "body": "{\"all\":true}"},
+			{"name": "hourly", "expression": "1 hour"}
+		]
+	}`)
+
+	p, err := m.GetProgInfo()
+	assert.NilError(t, err)
+	assert.Equal(t, "1 minute", p.Cron)
+	assert.DeepEqual(t, []*Schedule{
+		{Name: "nightly", Expression: "0 3 * * ? *", Action: "cleanup", Body: `{"all":true}`},
+		{Name: "hourly", Expression: "1 hour"},
+	}, p.Schedules)
+
+	// schedules are kept when the prog info is stored again
+	p.RemoveSchedule("hourly")
+	assert.NilError(t, m.StoreProgInfo(p))
+	p, err = m.GetProgInfo()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(p.Schedules))
+	assert.Equal(t, "nightly", p.GetSchedule("nightly").Name)
+
+	// prog info without schedules is still read
+	writeTestFile(t, m.progInfoPath, `{"id": "prog-id", "runtime": "python3.9"}`)
+	p, err = m.GetProgInfo()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(p.Schedules))
+}
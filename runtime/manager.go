@@ -12,7 +12,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	rt "runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -29,15 +33,20 @@ const (
 	// DefaultProject default project slug
 	DefaultProject = "default"
 
+	// number of bytes used to detect the content type of a file
+	sniffLen = 512
+
 	// drwxrw----
 	dirPermMode = 0760
 	// -rw-rw---
 	filePermMode = 0660
 )
 
+// Pattern a pattern of paths to skip or not skip
 type Pattern struct {
-	Value *regexp.Regexp
-	Skip  bool
+	Value   *regexp.Regexp
+	Skip    bool
+	DirOnly bool // only matches directories
 }
 
 var (
@@ -65,6 +74,9 @@ var (
 		Node:   []string{"_entry.js"},
 	}
 
+	// dirs of installed dependencies not searched for micros
+	dependencyDirs = []string{"node_modules", "__pycache__", "venv"}
+
 	// skipPaths maps runtimes to paths that should be skipped
 	skipPaths = map[string][]Pattern{
 		Python: {
@@ -107,8 +119,16 @@ type Manager struct {
 	userInfoPath string               // path to info file about the user
 	progInfoPath string               // path to info file about the program
 	statePath    string               // path to state file about the program
-	ignorePath   string               // path to .detaignore file
 	skipPaths    map[string][]Pattern // files that will be skipped
+	ignoreRules  map[string][]Pattern // patterns of .detaignore files mapped by dir
+	rehash       bool                 // if files should be hashed even if unchanged since stored state
+	concurrency  int                  // max number of files hashed concurrently
+	manifest     *Manifest            // manifest in the root dir, read once
+	manifestRead bool                 // if the manifest was read
+	includes     []*include           // dirs included with the root dir, resolved once
+	includesRead bool                 // if the includes were resolved
+	profile      string               // env profile, empty for the default profile
+	cliVersion   string               // version of the cli stored with the state and snapshots
 }
 
 // Runtime holds name and version of current runtime used
@@ -151,8 +171,6 @@ func NewManager(root *string, initDirs bool) (*Manager, error) {
 	}
 	userInfoPath := filepath.Join(home, detaDir, userInfoFile)
 
-	ignorePath := filepath.Join(rootDir, ignoreFile)
-
 	manager := &Manager{
 		rootDir:      rootDir,
 		detaPath:     detaPath,
@@ -160,67 +178,31 @@ func NewManager(root *string, initDirs bool) (*Manager, error) {
 		progInfoPath: filepath.Join(detaPath, progInfoFile),
 		statePath:    filepath.Join(detaPath, stateFile),
 		skipPaths:    skipPaths,
-		ignorePath:   ignorePath,
+		ignoreRules:  make(map[string][]Pattern),
+		concurrency:  rt.NumCPU(),
 	}
 
-	// not handling error as we don't want cli to crash if .detaignore is not found
-	manager.handleIgnoreFile()
-
 	return manager, nil
 }
 
-func (m *Manager) handleIgnoreFile() error {
-	runtime, err := m.GetRuntime()
-	if err != nil {
-		return err
-	}
-
-	contents, err := m.readFile(m.ignorePath)
-	if err != nil {
-		return err
-	}
-
-	lines, err := readLines(contents)
-	if err != nil {
-		return err
-	}
-
-	for _, line := range lines {
-		line = strings.Trim(line, SPACE)
-		if len(line) != 0 {
-			value, err := regexp.CompilePOSIX(line)
-			if err != nil {
-				continue // ignore current line and continue
-			}
-
-			pattern := Pattern{
-				Value: value,
-				Skip:  true,
-			}
-
-			firstChar := line[0]
-			if firstChar == NEGATION {
-				remainingChar := line[1:]
-				if len(remainingChar) == 0 {
-					continue // ignore current line and continue
-				}
-
-				value, err := regexp.CompilePOSIX(remainingChar)
-				if err != nil {
-					continue // ignore current line and continue
-				}
+// SetRehash sets if all files should be hashed when checking for changes
+// otherwise files with the same size and modification time as in the stored state are not hashed
+func (m *Manager) SetRehash(rehash bool) {
+	m.rehash = rehash
+}
 
-				pattern = Pattern{
-					Value: value,
-					Skip:  false,
-				}
-			}
+// SetCLIVersion sets the version of the cli stored with the state and snapshots
+func (m *Manager) SetCLIVersion(version string) {
+	m.cliVersion = version
+}
 
-			m.skipPaths[runtime.Name] = append([]Pattern{pattern}, m.skipPaths[runtime.Name]...)
-		}
+// SetConcurrency sets the max number of files hashed concurrently
+// uses the number of cpus if concurrency is less than 1
+func (m *Manager) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = rt.NumCPU()
 	}
-
-	return nil
+	m.concurrency = concurrency
 }
 
 // StoreProgInfo stores program info to disk
@@ -229,6 +211,10 @@ func (m *Manager) StoreProgInfo(p *ProgInfo) error {
 	if err != nil {
 		return err
 	}
+	err = os.MkdirAll(filepath.Dir(m.progInfoPath), dirPermMode)
+	if err != nil {
+		return err
+	}
 	return ioutil.WriteFile(m.progInfoPath, marshalled, filePermMode)
 }
 
@@ -291,6 +277,40 @@ func (m *Manager) IsInitialized() (bool, error) {
 	return true, nil
 }
 
+// FindMicros finds the root dirs of initialized micros in root and its sub dirs, sorted by path
+// hidden dirs, dependency dirs and dirs inside a micro are not searched
+func FindMicros(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || contains(dependencyDirs, name)) {
+			return filepath.SkipDir
+		}
+
+		_, err = os.Stat(filepath.Join(path, detaDir, progInfoFile))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		dirs = append(dirs, path)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
 // IsProgDirEmpty checks if dir contains any files/folders which are not hidden
 // if dir is nil, it sets the root dir
 func (m *Manager) IsProgDirEmpty() (bool, error) {
@@ -383,14 +403,29 @@ func (m *Manager) isHidden(path string) (bool, error) {
 }
 
 // should skip if the file or dir should be skipped
-func (m *Manager) shouldSkip(path string, runtime string) (bool, error) {
-	// do not skip .detaignore file
-	if regexp.MustCompile(ignoreFile).MatchString(path) {
+func (m *Manager) shouldSkip(path string, isDir bool, runtime string) (bool, error) {
+	path = filepath.ToSlash(path)
+	if path == "." {
 		return false, nil
 	}
 
+	// do not skip .detaignore files
+	_, filename := filepath.Split(path)
+	if filename == ignoreFile {
+		return false, nil
+	}
+
+	// patterns of .detaignore files take precedence over the default patterns
+	skip, matched, err := m.isIgnored(path, isDir)
+	if err != nil {
+		return false, err
+	}
+	if matched {
+		return skip, nil
+	}
+
 	for _, re := range m.skipPaths[runtime] {
-		if re.Value.MatchString(filepath.ToSlash(path)) {
+		if re.matches(path, isDir) {
 			return re.Skip, nil
 		}
 	}
@@ -413,44 +448,183 @@ func (m *Manager) readFile(path string) ([]byte, error) {
 	return ioutil.ReadAll(f)
 }
 
-// reads the contents of a file, returns contents and if file is binary or not
-func (m *Manager) readFileIsBinary(path string) ([]byte, bool, error) {
-	contents, err := m.readFile(path)
+// reads the contents and file info of a file
+// file info is read before the contents
+func (m *Manager) readFileInfo(path string) ([]byte, os.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	contents, err := ioutil.ReadAll(f)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, err
 	}
-	return contents, isBinary(contents), nil
+	return contents, info, nil
 }
 
-// calculates the sha256 sum of contents of file in path
-func (m *Manager) calcChecksum(path string) (string, error) {
-	contents, err := m.readFile(path)
+// localPath gets the path on disk of a slash separated path relative to the root dir
+func (m *Manager) localPath(path string) (string, error) {
+	inc, rel, err := m.includeOf(path)
 	if err != nil {
 		return "", err
 	}
-	hashSum := fmt.Sprintf("%x", sha256.Sum256(contents))
-	return hashSum, nil
+	if inc != nil {
+		return filepath.Join(inc.dir, filepath.FromSlash(rel)), nil
+	}
+	return filepath.Join(m.rootDir, filepath.FromSlash(path)), nil
 }
 
-// StoreState stores hashes of the current state of all files(not hidden) in the root program directory
-func (m *Manager) StoreState() error {
-	r, err := m.GetRuntime()
+// hashFile streams the contents of file in path to calculate the sha256 sum
+// only the first sniffLen bytes are used to check if the file is binary
+func (m *Manager) hashFile(path string) (*FileMeta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	h.Write(head[:n])
+
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMeta{
+		Size:     int64(n) + size,
+		ModTime:  info.ModTime().UnixNano(),
+		Checksum: fmt.Sprintf("%x", h.Sum(nil)),
+		Binary:   isBinary(head[:n]),
+		source:   path,
+	}, nil
+}
+
+// hashFiles hashes files concurrently with at most m.concurrency workers
+// paths are slash separated and relative to the root dir, results are in the same order as paths
+func (m *Manager) hashFiles(paths []string) ([]*FileMeta, error) {
+	files := make([]*FileMeta, len(paths))
+
+	workers := m.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	indexes := make(chan int)
+	// buffered so workers never block on errors
+	errs := make(chan error, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				path, err := m.localPath(paths[i])
+				if err != nil {
+					errs <- err
+					return
+				}
+				f, err := m.hashFile(path)
+				if err != nil {
+					errs <- err
+					return
+				}
+				files[i] = f
+			}
+		}()
+	}
+
+	var err error
+	// stop sending paths on first error
+send:
+	for i := range paths {
+		select {
+		case indexes <- i:
+		case err = <-errs:
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err == nil {
+		select {
+		case err = <-errs:
+		default:
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// walk walks the root dir and the included dirs and calls fn for every file that should not be skipped
+// path passed to fn is slash separated and relative to the root dir, files of included dirs are under their prefix
+func (m *Manager) walk(runtime string, fn func(path string, info os.FileInfo) error) error {
+	includes, err := m.getIncludes()
 	if err != nil {
 		return err
 	}
 
-	sm := make(stateMap)
-	err = filepath.Walk(m.rootDir, func(path string, info os.FileInfo, err error) error {
+	err = m.walkDir(m.rootDir, "", runtime, func(path string, info os.FileInfo) error {
+		for _, inc := range includes {
+			if _, ok := inc.contains(path); ok {
+				return fmt.Errorf("'%s' in the root directory conflicts with the prefix of include '%s'", path, inc.dir)
+			}
+		}
+		return fn(path, info)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, inc := range includes {
+		err = m.walkDir(inc.dir, inc.prefix, runtime, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkDir walks dir and calls fn for every file that should not be skipped
+// path passed to fn is slash separated and relative to dir under prefix
+func (m *Manager) walkDir(dir, prefix, runtime string, fn func(path string, info os.FileInfo) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		path, err = filepath.Rel(m.rootDir, path)
+		path, err = filepath.Rel(dir, path)
 		if err != nil {
 			return err
 		}
+		if prefix != "" {
+			path = filepath.Join(filepath.FromSlash(prefix), path)
+		}
 
-		shouldSkip, err := m.shouldSkip(path, r.Name)
+		shouldSkip, err := m.shouldSkip(path, info.IsDir(), runtime)
 		if err != nil {
 			return err
 		}
@@ -464,107 +638,182 @@ func (m *Manager) StoreState() error {
 		if shouldSkip {
 			return nil
 		}
+		return fn(filepath.ToSlash(path), info)
+	})
+}
 
-		hashSum, err := m.calcChecksum(filepath.Join(m.rootDir, path))
-		if err != nil {
+// StoreState stores hashes of the current state of all files(not hidden) in the root program directory
+func (m *Manager) StoreState() error {
+	r, err := m.GetRuntime()
+	if err != nil {
+		return err
+	}
+
+	storedState, err := m.getStoredState()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
 			return err
 		}
-		sm[filepath.ToSlash(path)] = hashSum
+		storedState = &state{}
+	}
+
+	sm := make(stateMap)
+	var toHash []string
+	err = m.walk(r.Name, func(path string, info os.FileInfo) error {
+		// skip hashing if file has not changed since stored
+		if stored, ok := storedState.Files[path]; ok && !m.rehash && stored.isUnchanged(info) {
+			sm[path] = stored
+			return nil
+		}
+		toHash = append(toHash, path)
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
-	marshalled, err := json.Marshal(sm)
+	files, err := m.hashFiles(toHash)
 	if err != nil {
 		return err
 	}
-
-	err = ioutil.WriteFile(m.statePath, marshalled, filePermMode)
-	if err != nil {
-		return err
+	for i, path := range toHash {
+		sm[path] = files[i].fileState()
 	}
-	return nil
+
+	storedState.Files = sm
+	// the stored files are the deployed files
+	storedState.Fingerprint = fingerprint(sm.checksums())
+	return m.storeState(storedState)
 }
 
 // gets the current stored state
-func (m *Manager) getStoredState() (stateMap, error) {
+func (m *Manager) getStoredState() (*state, error) {
 	contents, err := m.readFile(m.statePath)
 	if err != nil {
 		return nil, err
 	}
-	s, err := stateMapFromBytes(contents)
+	s, err := stateFromBytes(contents)
 	if err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
-// readAll reads all the files and returns the contents as stateChanges
-func (m *Manager) readAll() (*StateChanges, error) {
-	r, err := m.GetRuntime()
+// stores the state in the current state format
+func (m *Manager) storeState(s *state) error {
+	s.Version = stateVersion
+	s.CLIVersion = m.cliVersion
+
+	progInfo, err := m.GetProgInfo()
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if progInfo != nil {
+		s.ProgramID = progInfo.ID
 	}
 
-	sc := &StateChanges{
-		Changes:     make(map[string]string),
-		BinaryFiles: make(map[string]string),
+	marshalled, err := json.Marshal(s)
+	if err != nil {
+		return err
 	}
+	return ioutil.WriteFile(m.statePath, marshalled, filePermMode)
+}
 
-	err = filepath.Walk(m.rootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+// UpdateState updates the stored state with the checksums and deletions of changes
+// used to store the state of files as they are deployed
+func (m *Manager) UpdateState(sc *StateChanges) error {
+	s, err := m.getStoredState()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
 			return err
 		}
-		path, err = filepath.Rel(m.rootDir, path)
-		if err != nil {
-			return err
+		s = &state{
+			Files: make(stateMap),
 		}
+	}
 
-		shouldSkip, err := m.shouldSkip(path, r.Name)
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			if shouldSkip {
-				return filepath.SkipDir
+	for path, f := range sc.Files {
+		s.Files[path] = f.fileState()
+	}
+	for _, path := range sc.Deletions {
+		delete(s.Files, path)
+	}
+	s.DeployedAt = time.Now().UTC()
+	s.Fingerprint = fingerprint(s.Files.checksums())
+	return m.storeState(s)
+}
+
+// ReadContents reads the contents of the changed files into Changes and BinaryFiles
+// checksums and sizes are updated from the contents read in case the files changed since
+func (m *Manager) ReadContents(sc *StateChanges) error {
+	for path, f := range sc.Files {
+		source := f.source
+		if source == "" {
+			var err error
+			source, err = m.localPath(path)
+			if err != nil {
+				return err
 			}
-			return nil
-		}
-		if shouldSkip {
-			return nil
 		}
-
-		contents, isBinary, err := m.readFileIsBinary(filepath.Join(m.rootDir, path))
+		contents, info, err := m.readFileInfo(source)
 		if err != nil {
 			return err
 		}
+		f.Size = int64(len(contents))
+		// contents read from the history carry no modification time
+		if f.ModTime != 0 {
+			f.ModTime = info.ModTime().UnixNano()
+		}
+		f.Checksum = fmt.Sprintf("%x", sha256.Sum256(contents))
+		f.Binary = isBinary(contents)
 
-		if isBinary {
-			sc.BinaryFiles[filepath.ToSlash(path)] = base64.StdEncoding.EncodeToString(contents)
+		if f.Binary {
+			sc.BinaryFiles[path] = base64.StdEncoding.EncodeToString(contents)
 		} else {
-			sc.Changes[filepath.ToSlash(path)] = string(contents)
+			sc.Changes[path] = string(contents)
 		}
+	}
+	return nil
+}
+
+// readAll hashes all the files and returns them as stateChanges
+func (m *Manager) readAll() (*StateChanges, error) {
+	r, err := m.GetRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	err = m.walk(r.Name, func(path string, info os.FileInfo) error {
+		paths = append(paths, path)
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+
+	files, err := m.hashFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := newStateChanges()
+	for i, path := range paths {
+		sc.Files[path] = files[i]
+		sc.Additions = append(sc.Additions, path)
+	}
 	return sc, nil
 }
 
 // GetChanges checks if the state has changed in the root directory
+// contents of changed files are not read, see ReadContents
 func (m *Manager) GetChanges() (*StateChanges, error) {
 	r, err := m.GetRuntime()
 	if err != nil {
 		return nil, err
 	}
 
-	sc := &StateChanges{
-		Changes:     make(map[string]string),
-		BinaryFiles: make(map[string]string),
-	}
+	sc := newStateChanges()
 
 	storedState, err := m.getStoredState()
 	if err != nil {
@@ -576,60 +825,40 @@ func (m *Manager) GetChanges() (*StateChanges, error) {
 
 	// mark all paths in current state as deleted
 	// if seen later on walk, remove from deletions
-	deletions := make(map[string]struct{}, len(storedState))
-	for k := range storedState {
+	deletions := make(map[string]struct{}, len(storedState.Files))
+	for k := range storedState.Files {
 		deletions[k] = struct{}{}
 	}
 
-	err = filepath.Walk(m.rootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		path, err = filepath.Rel(m.rootDir, path)
-		if err != nil {
-			return err
-		}
-		shouldSkip, err := m.shouldSkip(path, r.Name)
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			if shouldSkip {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if shouldSkip {
-			return nil
-		}
-
+	var toHash []string
+	err = m.walk(r.Name, func(path string, info os.FileInfo) error {
 		// update deletions
-		if _, ok := deletions[filepath.ToSlash(path)]; ok {
-			delete(deletions, filepath.ToSlash(path))
-		}
+		delete(deletions, path)
 
-		checksum, err := m.calcChecksum(filepath.Join(m.rootDir, path))
-		if err != nil {
-			return err
-		}
-
-		if storedState[filepath.ToSlash(path)] != checksum {
-			contents, isBinary, err := m.readFileIsBinary(filepath.Join(m.rootDir, path))
-			if err != nil {
-				return err
-			}
-			if isBinary {
-				sc.BinaryFiles[filepath.ToSlash(path)] = base64.StdEncoding.EncodeToString(contents)
-			} else {
-				sc.Changes[filepath.ToSlash(path)] = string(contents)
-			}
+		// skip hashing if file has not changed since stored
+		if stored, ok := storedState.Files[path]; ok && !m.rehash && stored.isUnchanged(info) {
+			return nil
 		}
+		toHash = append(toHash, path)
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
+	files, err := m.hashFiles(toHash)
 	if err != nil {
 		return nil, err
 	}
+	for i, path := range toHash {
+		stored, ok := storedState.Files[path]
+		if !ok || stored.Checksum != files[i].Checksum {
+			sc.Files[path] = files[i]
+			if !ok {
+				sc.Additions = append(sc.Additions, path)
+			}
+		}
+	}
 
 	sc.Deletions = make([]string, len(deletions))
 	i := 0
@@ -637,8 +866,9 @@ func (m *Manager) GetChanges() (*StateChanges, error) {
 		sc.Deletions[i] = k
 		i++
 	}
+	sort.Strings(sc.Deletions)
 
-	if len(sc.Changes) == 0 && len(sc.Deletions) == 0 && len(sc.BinaryFiles) == 0 {
+	if len(sc.Files) == 0 && len(sc.Deletions) == 0 {
 		return nil, nil
 	}
 	return sc, nil
@@ -869,17 +1099,12 @@ func (m *Manager) WriteProgramFiles(zipFile []byte, targetDir *string, relative
 }
 
 // Clean removes `.deta` folder created by the runtime manager if it's empty
+// for an env profile only the empty dirs of the profile are removed
 func (m *Manager) Clean() error {
-	isEmpty, err := isDirEmpty(m.detaPath)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
-		}
-		return err
-	}
-	// only delete `m.detaPath` if it's empty
-	if isEmpty {
-		return os.RemoveAll(m.detaPath)
+	dirs := m.profileDirs()
+	// the deta dir is shared with other profiles
+	if m.profile != "" {
+		dirs = dirs[:len(dirs)-1]
 	}
-	return nil
+	return removeEmptyDirs(dirs...)
 }
@@ -0,0 +1,243 @@
+package runtime
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+var (
+	// tmp dirs of tests, managers of the same test are created in the same tmp dir
+	testDirs   = make(map[*testing.T]string)
+	testDirsMu sync.Mutex
+)
+
+// testDir gets the tmp dir of the test, removed when the test finishes
+func testDir(t *testing.T) string {
+	testDirsMu.Lock()
+	defer testDirsMu.Unlock()
+	dir, ok := testDirs[t]
+	if !ok {
+		dir = t.TempDir()
+		testDirs[t] = dir
+		t.Cleanup(func() {
+			testDirsMu.Lock()
+			defer testDirsMu.Unlock()
+			delete(testDirs, t)
+		})
+	}
+	return dir
+}
+
+// newTestManager writes files to a root dir named name in the tmp dir of the test and returns a manager for it
+// root dirs of managers of the same test are siblings
+func newTestManager(t *testing.T, name string, files map[string]string) *Manager {
+	rootDir := filepath.Join(testDir(t), name)
+
+	detaPath := filepath.Join(rootDir, detaDir)
+	err := os.MkdirAll(detaPath, dirPermMode)
+	if err != nil {
+		t.Fatalf("failed to create dir %s: %v", detaPath, err)
+	}
+	for name, content := range files {
+		writeTestFile(t, filepath.Join(rootDir, filepath.FromSlash(name)), content)
+	}
+
+	return &Manager{
+		rootDir:      rootDir,
+		detaPath:     detaPath,
+		progInfoPath: filepath.Join(detaPath, progInfoFile),
+		statePath:    filepath.Join(detaPath, stateFile),
+		skipPaths:    skipPaths,
+		ignoreRules:  make(map[string][]Pattern),
+	}
+}
+
+// testLocalPath gets the path on disk of a path relative to the root dir of m
+func testLocalPath(t *testing.T, m *Manager, path string) string {
+	localPath, err := m.localPath(path)
+	if err != nil {
+		t.Fatalf("failed to get local path of %s: %v", path, err)
+	}
+	return localPath
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		t.Fatalf("failed to create dir for %s: %v", path, err)
+	}
+	err = ioutil.WriteFile(path, []byte(content), filePermMode)
+	if err != nil {
+		t.Fatalf("failed to write file %s: %v", path, err)
+	}
+}
+
+// newTestZip creates a zip file of files
+func newTestZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create %s in zip: %v", name, err)
+		}
+		_, err = f.Write([]byte(content))
+		if err != nil {
+			t.Fatalf("failed to write %s in zip: %v", name, err)
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetChangesFastPath(t *testing.T) {
+	m := newTestManager(t, "fast_path", map[string]string{
+		"main.py":       "print('hello')",
+		"lib/utils.py":  "x = 1",
+		"static/a.html": "<html></html>",
+	})
+
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.Equal(t, 3, len(sc.Files))
+	assert.NilError(t, m.UpdateState(sc))
+
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.Assert(t, sc == nil)
+
+	// change contents keeping the same size and modification time
+	path := testLocalPath(t, m, "lib/utils.py")
+	info, err := os.Stat(path)
+	assert.NilError(t, err)
+	writeTestFile(t, path, "x = 2")
+	assert.NilError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.Assert(t, sc == nil)
+
+	// rehash finds the change
+	m.SetRehash(true)
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(sc.Files))
+	assert.Assert(t, sc.Files["lib/utils.py"] != nil)
+	m.SetRehash(false)
+
+	// a different modification time is hashed
+	writeTestFile(t, testLocalPath(t, m, "main.py"), "print('hello')")
+	assert.NilError(t, os.Chtimes(testLocalPath(t, m, "main.py"), time.Now(), time.Now().Add(time.Minute)))
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.Assert(t, sc == nil)
+}
+
+func TestStateFromBytes(t *testing.T) {
+	// only checksums before file metadata was stored
+	s, err := stateFromBytes([]byte(`{"main.py":"checksum","version":"checksum-v"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, 0, s.Version)
+	assert.DeepEqual(t, s.Files, stateMap{
+		"main.py": {Checksum: "checksum"},
+		"version": {Checksum: "checksum-v"},
+	})
+
+	// map of files before versioning
+	s, err = stateFromBytes([]byte(`{"main.py":{"checksum":"checksum","size":10,"mtime":20}}`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, s.Files, stateMap{"main.py": {Checksum: "checksum", Size: 10, ModTime: 20}})
+
+	s, err = stateFromBytes([]byte(`{"version":1,"cli_version":"v1.2.0","program_id":"id","deployed_at":"2021-09-16T10:00:00Z","files":{"main.py":{"checksum":"checksum","size":10,"mtime":20}}}`))
+	assert.NilError(t, err)
+	assert.Equal(t, 1, s.Version)
+	assert.Equal(t, "v1.2.0", s.CLIVersion)
+	assert.Equal(t, "id", s.ProgramID)
+	assert.Equal(t, time.Date(2021, 9, 16, 10, 0, 0, 0, time.UTC), s.DeployedAt)
+	assert.DeepEqual(t, s.Files, stateMap{"main.py": {Checksum: "checksum", Size: 10, ModTime: 20}})
+
+	_, err = stateFromBytes([]byte(`{"version":100,"files":{}}`))
+	assert.ErrorContains(t, err, "unsupported state version")
+}
+
+func TestStateMigration(t *testing.T) {
+	m := newTestManager(t, "state_migration", map[string]string{
+		"main.py": "print('hello')",
+	})
+	writeTestFile(t, m.progInfoPath, `{"id":"prog-id","runtime":"python3.9"}`)
+	writeTestFile(t, m.statePath, `{"main.py":"8b1b3a6f1e5e5d7c14bb1c6a29e2f1a7e8f6cd45e4e10c2c58b1c0fbc1e4f2a1"}`)
+
+	// changes are found against the old state
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(sc.Files))
+	assert.NilError(t, m.UpdateState(sc))
+
+	s, err := m.getStoredState()
+	assert.NilError(t, err)
+	assert.Equal(t, stateVersion, s.Version)
+	assert.Equal(t, "prog-id", s.ProgramID)
+	assert.Assert(t, !s.DeployedAt.IsZero())
+	assert.Equal(t, sc.Files["main.py"].Checksum, s.Files["main.py"].Checksum)
+
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.Assert(t, sc == nil)
+}
+
+func TestHashFiles(t *testing.T) {
+	files := make(map[string]string)
+	var paths []string
+	for i := 0; i < 50; i++ {
+		path := fmt.Sprintf("file_%02d.txt", i)
+		files[path] = strings.Repeat("a", i)
+		paths = append(paths, path)
+	}
+	m := newTestManager(t, "hash_files", files)
+
+	for _, concurrency := range []int{0, 1, 4, 100} {
+		m.SetConcurrency(concurrency)
+		hashed, err := m.hashFiles(paths)
+		assert.NilError(t, err)
+		assert.Equal(t, len(paths), len(hashed))
+		// results are in the same order as paths
+		for i, f := range hashed {
+			assert.Equal(t, int64(i), f.Size)
+		}
+	}
+
+	_, err := m.hashFiles(append(paths, "missing.txt"))
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestFindMicros(t *testing.T) {
+	m := newTestManager(t, "find_micros", map[string]string{
+		"a/.deta/prog_info":                "{}",
+		"a/nested/.deta/prog_info":         "{}",
+		"b/c/.deta/prog_info":              "{}",
+		"b/node_modules/d/.deta/prog_info": "{}",
+		".hidden/.deta/prog_info":          "{}",
+		"not_a_micro/main.py":              "",
+	})
+
+	dirs, err := FindMicros(m.rootDir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{
+		filepath.Join(m.rootDir, "a"),
+		filepath.Join(m.rootDir, "b", "c"),
+	}, dirs)
+}
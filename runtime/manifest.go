@@ -0,0 +1,108 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// manifest files in the root dir, only one of them can be present
+	manifestFiles = []string{"deta.json", "deta.yaml", "deta.yml"}
+
+	// visor modes in a manifest
+	visorModes = []string{"debug", "off"}
+)
+
+// Manifest declarative configuration of a micro checked in with the code
+// fields not present in the manifest are not managed by the manifest
+type Manifest struct {
+	Name    string    `json:"name,omitempty" yaml:"name,omitempty"`
+	Project string    `json:"project,omitempty" yaml:"project,omitempty"`
+	Runtime string    `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+	Env     string    `json:"env,omitempty" yaml:"env,omitempty"`     // path to the env file relative to the root dir
+	Cron    *string   `json:"cron,omitempty" yaml:"cron,omitempty"`   // an empty expression removes the schedule
+	Visor   string    `json:"visor,omitempty" yaml:"visor,omitempty"` // 'debug' or 'off'
+	Auth    *bool     `json:"auth,omitempty" yaml:"auth,omitempty"`   // http auth
+	Ignore  []string  `json:"ignore,omitempty" yaml:"ignore,omitempty"`
+	Include []Include `json:"include,omitempty" yaml:"include,omitempty"` // dirs outside the root dir deployed with the code
+
+	// manifest file the manifest was read from
+	file string
+}
+
+// File gets the name of the file the manifest was read from
+func (mf *Manifest) File() string {
+	return mf.file
+}
+
+// unmarshals data of a manifest file into a Manifest
+func manifestFromBytes(file string, data []byte) (*Manifest, error) {
+	var mf Manifest
+	var err error
+	if strings.HasSuffix(file, ".json") {
+		// unknown fields are rejected like with yaml
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&mf)
+		if err == nil && decoder.Decode(&struct{}{}) != io.EOF {
+			err = errors.New("invalid data after the top-level value")
+		}
+	} else {
+		err = yaml.UnmarshalStrict(data, &mf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %v", file, err)
+	}
+	mf.file = file
+
+	if mf.Visor != "" && !contains(visorModes, mf.Visor) {
+		return nil, fmt.Errorf("invalid visor mode '%s' in '%s', expected one of %s", mf.Visor, file, strings.Join(visorModes, ", "))
+	}
+	return &mf, nil
+}
+
+// GetManifest gets the manifest in the root dir, nil if no manifest is present
+// the manifest is read once and cached
+func (m *Manager) GetManifest() (*Manifest, error) {
+	if m.manifestRead {
+		return m.manifest, nil
+	}
+
+	var found []string
+	for _, file := range manifestFiles {
+		contents, err := m.readFile(filepath.Join(m.rootDir, file))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = append(found, file)
+		if len(found) > 1 {
+			return nil, fmt.Errorf("multiple manifest files '%s' present, only one is supported", strings.Join(found, "', '"))
+		}
+		m.manifest, err = manifestFromBytes(file, contents)
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.manifestRead = true
+	return m.manifest, nil
+}
+
+// manifestPatterns gets patterns of the ignore rules of the manifest
+func (m *Manager) manifestPatterns() ([]Pattern, error) {
+	mf, err := m.GetManifest()
+	if err != nil || mf == nil {
+		return nil, err
+	}
+	return parseIgnoreFile([]byte(strings.Join(mf.Ignore, "\n")))
+}
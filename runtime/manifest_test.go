@@ -0,0 +1,96 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestManifestFromBytes(t *testing.T) {
+	cron := "5 minutes"
+	auth := true
+	expected := &Manifest{
+		Name:    "my-micro",
+		Runtime: "python3.9",
+		Env:     ".env",
+		Cron:    &cron,
+		Visor:   "debug",
+		Auth:    &auth,
+		Ignore:  []string{"*.log"},
+	}
+
+	testCases := []struct {
+		file     string
+		contents string
+		err      bool
+	}{
+		{"deta.json", `{"name": "my-micro", "runtime": "python3.9", "env": ".env", "cron": "5 minutes", "visor": "debug", "auth": true, "ignore": ["*.log"]}`, false},
+		{"deta.yaml", "name: my-micro\nruntime: python3.9\nenv: .env\ncron: 5 minutes\nvisor: debug\nauth: true\nignore:\n  - '*.log'\n", false},
+		{"deta.yaml", "name: my-micro\nunknown: field\n", true},
+		{"deta.json", `{"name": "my-micro", "visor": "on"}`, true},
+		{"deta.json", `{"name": `, true},
+		{"deta.json", `{"name": "my-micro", "crons": "5 minutes"}`, true},
+		{"deta.json", `{"name": "my-micro"} {}`, true},
+	}
+
+	for _, tc := range testCases {
+		msg := fmt.Sprintf("file: %s, contents: %s", tc.file, tc.contents)
+		mf, err := manifestFromBytes(tc.file, []byte(tc.contents))
+		if tc.err {
+			assert.Assert(t, err != nil, msg)
+			continue
+		}
+		assert.NilError(t, err, msg)
+		assert.Equal(t, tc.file, mf.File(), msg)
+		mf.file = ""
+		assert.Assert(t, reflect.DeepEqual(expected, mf), msg)
+	}
+}
+
+func TestGetManifest(t *testing.T) {
+	m := newTestManager(t, "no_manifest", map[string]string{
+		"main.py": "",
+	})
+	mf, err := m.GetManifest()
+	assert.NilError(t, err)
+	assert.Assert(t, mf == nil)
+
+	m = newTestManager(t, "multiple_manifests", map[string]string{
+		"deta.json": "{}",
+		"deta.yml":  "",
+	})
+	_, err = m.GetManifest()
+	assert.ErrorContains(t, err, "multiple manifest files")
+}
+
+func TestShouldSkipManifestIgnore(t *testing.T) {
+	m := newTestManager(t, "manifest_ignore", map[string]string{
+		"deta.yaml": "ignore:\n  - '*.log'\n  - '*.txt'\n",
+		ignoreFile:  "!keep.txt\n",
+		"sub/a.log": "",
+		"keep.txt":  "",
+		"other.txt": "",
+		"sub/a.py":  "",
+		"sub/b.txt": "",
+	})
+
+	testCases := []struct {
+		path string
+		skip bool
+	}{
+		{"sub/a.log", true},
+		{"other.txt", true},
+		{"keep.txt", false},
+		{"sub/a.py", false},
+		{"sub/b.txt", true},
+		{"deta.yaml", false},
+	}
+	for _, tc := range testCases {
+		skip, err := m.shouldSkip(filepath.FromSlash(tc.path), false, Python)
+		assert.NilError(t, err)
+		assert.Equal(t, tc.skip, skip, fmt.Sprintf("path: %s", tc.path))
+	}
+}
@@ -0,0 +1,419 @@
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// conflict markers written in files changed both locally and in the deployed code
+	conflictStart = "<<<<<<< local\n"
+	conflictSep   = "=======\n"
+	conflictEnd   = ">>>>>>> deployed\n"
+)
+
+var (
+	// ErrMergeConflicts files changed both locally and in the deployed code
+	ErrMergeConflicts = errors.New("files changed both locally and in the deployed code")
+)
+
+// PullResult files changed in the root dir by merging the deployed code
+type PullResult struct {
+	Updated   []string // files written with the deployed contents
+	Deleted   []string // files deleted in the deployed code
+	Merged    []string // files changed on both sides merged without conflicts
+	Conflicts []string // files changed on both sides that could not be merged
+	Included  []string // files of include dirs changed in the deployed code, not changed locally
+	// reasons of conflicts not written with conflict markers mapped by path
+	Reasons map[string]string
+}
+
+// addConflict adds a conflict not written with conflict markers
+func (r *PullResult) addConflict(path, reason string) {
+	if r.Reasons == nil {
+		r.Reasons = make(map[string]string)
+	}
+	r.Conflicts = append(r.Conflicts, path)
+	r.Reasons[path] = reason
+}
+
+func (r *PullResult) sort() {
+	sort.Strings(r.Updated)
+	sort.Strings(r.Deleted)
+	sort.Strings(r.Merged)
+	sort.Strings(r.Conflicts)
+	sort.Strings(r.Included)
+}
+
+// lineMatches maps lines of base to the lines of other they are unchanged in, -1 if changed
+func lineMatches(base, other []string) []int {
+	matches := make([]int, len(base))
+	for i := range matches {
+		matches[i] = -1
+	}
+	for _, op := range diffLines(base, other) {
+		if op.kind == ' ' {
+			matches[op.oldIndex] = op.newIndex
+		}
+	}
+	return matches
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeConflictLines writes lines of one side of a conflict ending with a new line
+func writeConflictLines(b *strings.Builder, lines []string) {
+	for _, l := range lines {
+		b.WriteString(l)
+		if !strings.HasSuffix(l, "\n") {
+			b.WriteString("\n")
+		}
+	}
+}
+
+// merge3 merges the changes from base to local and from base to remote line by line
+// lines changed differently on both sides are written between conflict markers
+func merge3(base, local, remote []byte) (merged []byte, conflicts int) {
+	o, a, b := splitLines(base), splitLines(local), splitLines(remote)
+	matchA, matchB := lineMatches(o, a), lineMatches(o, b)
+
+	var out strings.Builder
+	oi, ai, bi := 0, 0, 0
+	for {
+		// lines unchanged on both sides
+		k := 0
+		for oi+k < len(o) && matchA[oi+k] == ai+k && matchB[oi+k] == bi+k {
+			k++
+		}
+		if k > 0 {
+			for _, l := range o[oi : oi+k] {
+				out.WriteString(l)
+			}
+			oi, ai, bi = oi+k, ai+k, bi+k
+			continue
+		}
+
+		// next line of base unchanged on both sides ends the changed chunk
+		next := oi
+		for next < len(o) && (matchA[next] == -1 || matchB[next] == -1) {
+			next++
+		}
+		endA, endB := len(a), len(b)
+		if next < len(o) {
+			endA, endB = matchA[next], matchB[next]
+		}
+		chunkO, chunkA, chunkB := o[oi:next], a[ai:endA], b[bi:endB]
+		if len(chunkO) == 0 && len(chunkA) == 0 && len(chunkB) == 0 {
+			break
+		}
+
+		switch {
+		case equalLines(chunkA, chunkO):
+			out.WriteString(strings.Join(chunkB, ""))
+		case equalLines(chunkB, chunkO), equalLines(chunkA, chunkB):
+			out.WriteString(strings.Join(chunkA, ""))
+		default:
+			conflicts++
+			if s := out.String(); len(s) > 0 && !strings.HasSuffix(s, "\n") {
+				out.WriteString("\n")
+			}
+			out.WriteString(conflictStart)
+			writeConflictLines(&out, chunkA)
+			out.WriteString(conflictSep)
+			writeConflictLines(&out, chunkB)
+			out.WriteString(conflictEnd)
+		}
+		oi, ai, bi = next, endA, endB
+	}
+	return []byte(out.String()), conflicts
+}
+
+// pull action to apply to a file of the root dir
+type pullAction struct {
+	path     string
+	contents []byte
+	delete   bool
+}
+
+// Pull merges the deployed code in zipFile into the root dir using the stored state as the base
+// changes only in the deployed code are applied and changes only in the root dir are kept
+// if writeConflicts is false nothing is written and ErrMergeConflicts is returned on conflicts
+// the stored state is updated to the deployed code
+func (m *Manager) Pull(zipFile []byte, writeConflicts bool) (*PullResult, error) {
+	r, err := m.GetRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := m.getStoredState()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		s = &state{}
+	}
+	if s.Files == nil {
+		s.Files = make(stateMap)
+	}
+
+	remote, err := m.readDeployed(zipFile, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	remoteSums := deployedChecksums(remote)
+
+	local, err := m.hashLocal(r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]struct{})
+	for path := range remote {
+		paths[path] = struct{}{}
+	}
+	for path := range local {
+		paths[path] = struct{}{}
+	}
+	for path := range s.Files {
+		paths[path] = struct{}{}
+	}
+
+	result := &PullResult{}
+	var actions []pullAction
+	for path := range paths {
+		var baseSum, localSum, remoteSum string
+		if f, ok := s.Files[path]; ok {
+			baseSum = f.Checksum
+		}
+		if f, ok := local[path]; ok {
+			localSum = f.Checksum
+		}
+		remoteContents, inRemote := remote[path]
+		remoteSum = remoteSums[path]
+
+		if localSum == remoteSum || remoteSum == baseSum {
+			// same on both sides or only changed locally
+			continue
+		}
+
+		// files of include dirs are shared with other dirs, the deployed code does not change them
+		inc, _, err := m.includeOf(path)
+		if err != nil {
+			return nil, err
+		}
+		if inc != nil {
+			result.Included = append(result.Included, path)
+			continue
+		}
+
+		if localSum == baseSum {
+			// only changed in the deployed code
+			actions = append(actions, pullAction{path: path, contents: remoteContents, delete: !inRemote})
+			if inRemote {
+				result.Updated = append(result.Updated, path)
+			} else {
+				result.Deleted = append(result.Deleted, path)
+			}
+			continue
+		}
+
+		// changed on both sides
+		if localSum == "" {
+			// deleted locally, restore the deployed file to not lose the changes
+			actions = append(actions, pullAction{path: path, contents: remoteContents})
+			result.addConflict(path, "deleted locally, restored the deployed file")
+			continue
+		}
+		if !inRemote {
+			result.addConflict(path, "deleted in the deployed code, kept the local file")
+			continue
+		}
+		if local[path].Binary || isBinary(remoteContents) {
+			result.addConflict(path, "binary file, kept the local file")
+			continue
+		}
+
+		localPath, err := m.localPath(path)
+		if err != nil {
+			return nil, err
+		}
+		localContents, err := m.readFile(localPath)
+		if err != nil {
+			return nil, err
+		}
+		// files added on both sides are merged without a base
+		var baseContents []byte
+		if baseSum != "" {
+			baseContents, err = m.readFile(m.objectPath(baseSum))
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					return nil, err
+				}
+				// merging without the base would mark every line as a conflict
+				result.addConflict(path, "last deployed version not in the history, kept the local file")
+				continue
+			}
+		}
+		merged, conflicts := merge3(baseContents, localContents, remoteContents)
+		actions = append(actions, pullAction{path: path, contents: merged})
+		if conflicts > 0 {
+			result.Conflicts = append(result.Conflicts, path)
+		} else {
+			result.Merged = append(result.Merged, path)
+		}
+	}
+	result.sort()
+
+	if len(result.Conflicts) > 0 && !writeConflicts {
+		return result, ErrMergeConflicts
+	}
+
+	for _, a := range actions {
+		path, err := m.localPath(a.path)
+		if err != nil {
+			return nil, err
+		}
+		if a.delete {
+			err = os.Remove(path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			continue
+		}
+		err = os.MkdirAll(filepath.Dir(path), dirPermMode)
+		if err != nil {
+			return nil, err
+		}
+		err = ioutil.WriteFile(path, a.contents, filePermMode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = m.storeDeployedState(s, remote, remoteSums)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// hashLocal gets the meta of the files in the root dir deployed with the micro mapped by path
+func (m *Manager) hashLocal(runtime string) (map[string]*FileMeta, error) {
+	var localPaths []string
+	err := m.walk(runtime, func(path string, info os.FileInfo) error {
+		localPaths = append(localPaths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	localFiles, err := m.hashFiles(localPaths)
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string]*FileMeta, len(localPaths))
+	for i, path := range localPaths {
+		local[path] = localFiles[i]
+	}
+	return local, nil
+}
+
+// storeDeployedState stores the deployed files in remote as the state s
+// the deployed files are stored in the history as the base of the next pull
+func (m *Manager) storeDeployedState(s *state, remote map[string][]byte, remoteSums map[string]string) error {
+	err := os.MkdirAll(filepath.Join(m.historyPath(), objectsDir), dirPermMode)
+	if err != nil {
+		return err
+	}
+	files := make(stateMap, len(remote))
+	for path, contents := range remote {
+		checksum := remoteSums[path]
+		objectPath := m.objectPath(checksum)
+		if _, err := os.Stat(objectPath); err != nil {
+			err = ioutil.WriteFile(objectPath, contents, filePermMode)
+			if err != nil {
+				return err
+			}
+		}
+
+		// only the checksum is stored for files that differ from the deployed code
+		// so that they are deployed on the next deploy
+		f := &fileState{
+			Checksum: checksum,
+			Size:     int64(len(contents)),
+		}
+		localPath, err := m.localPath(path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(localPath)
+		if err == nil {
+			localContents, err := m.readFile(localPath)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(localContents, contents) {
+				f.ModTime = info.ModTime().UnixNano()
+			}
+		}
+		files[path] = f
+	}
+	s.Files = files
+	s.Fingerprint = fingerprint(remoteSums)
+
+	return m.storeState(s)
+}
+
+// Link stores the deployed code in zipFile as the state of the root dir without changing any files
+// returns the files that differ from the deployed code sorted by path, they are deployed on the next deploy
+func (m *Manager) Link(zipFile []byte) ([]string, error) {
+	r, err := m.GetRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := m.readDeployed(zipFile, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	remoteSums := deployedChecksums(remote)
+
+	local, err := m.hashLocal(r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var differ []string
+	for path, f := range local {
+		if remoteSums[path] != f.Checksum {
+			differ = append(differ, path)
+		}
+	}
+	for path := range remote {
+		if _, ok := local[path]; !ok {
+			differ = append(differ, path)
+		}
+	}
+	sort.Strings(differ)
+
+	err = m.storeDeployedState(&state{}, remote, remoteSums)
+	if err != nil {
+		return nil, err
+	}
+	return differ, nil
+}
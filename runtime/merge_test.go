@@ -0,0 +1,170 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMerge3(t *testing.T) {
+	testCases := []struct {
+		base      string
+		local     string
+		remote    string
+		merged    string
+		conflicts int
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", "a\nx\nc\n", "a\nx\nc\n", 0},
+		{"a\nb\nc\n", "a\nx\nc\n", "a\nb\nc\n", "a\nx\nc\n", 0},
+		{"a\nb\nc\nd\ne\n", "x\nb\nc\nd\ne\n", "a\nb\nc\nd\ny\n", "x\nb\nc\nd\ny\n", 0},
+		{"a\nb\nc\n", "a\nx\nc\n", "a\nx\nc\n", "a\nx\nc\n", 0},
+		{"a\nb\nc\n", "a\nx\nc\n", "a\ny\nc\n", "a\n<<<<<<< local\nx\n=======\ny\n>>>>>>> deployed\nc\n", 1},
+		{"", "a\n", "b", "<<<<<<< local\na\n=======\nb\n>>>>>>> deployed\n", 1},
+		{"a\nb\n", "a\nb\nc\n", "z\na\nb\n", "z\na\nb\nc\n", 0},
+	}
+
+	for _, tc := range testCases {
+		msg := fmt.Sprintf("base: %q, local: %q, remote: %q", tc.base, tc.local, tc.remote)
+		merged, conflicts := merge3([]byte(tc.base), []byte(tc.local), []byte(tc.remote))
+		assert.Equal(t, tc.merged, string(merged), msg)
+		assert.Equal(t, tc.conflicts, conflicts, msg)
+	}
+}
+
+func TestPull(t *testing.T) {
+	m := newTestManager(t, "pull", map[string]string{
+		"main.py":      "a\nb\nc\nd\ne\n",
+		"local.py":     "base\n",
+		"remote.py":    "base\n",
+		"deleted.py":   "base\n",
+		"conflict.py":  "base\n",
+		"local_new.py": "local\n",
+	})
+
+	base := newTestZip(t, map[string]string{
+		"main.py":     "a\nb\nc\nd\ne\n",
+		"local.py":    "base\n",
+		"remote.py":   "base\n",
+		"deleted.py":  "base\n",
+		"conflict.py": "base\n",
+	})
+	_, err := m.Pull(base, true)
+	assert.NilError(t, err)
+
+	writeTestFile(t, testLocalPath(t, m, "main.py"), "x\nb\nc\nd\ne\n")
+	writeTestFile(t, testLocalPath(t, m, "local.py"), "local\n")
+	writeTestFile(t, testLocalPath(t, m, "conflict.py"), "local\n")
+
+	deployed := newTestZip(t, map[string]string{
+		"main.py":       "a\nb\nc\nd\ny\n",
+		"local.py":      "base\n",
+		"remote.py":     "remote\n",
+		"conflict.py":   "remote\n",
+		"remote_new.py": "remote\n",
+		"_entry.py":     "lib\n",
+	})
+
+	// nothing is written on conflicts if conflicts are not written
+	result, err := m.Pull(deployed, false)
+	assert.Assert(t, errors.Is(err, ErrMergeConflicts))
+	assert.DeepEqual(t, []string{"conflict.py"}, result.Conflicts)
+	assertTestFile(t, testLocalPath(t, m, "remote.py"), "base\n")
+
+	result, err = m.Pull(deployed, true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, &PullResult{
+		Updated:   []string{"remote.py", "remote_new.py"},
+		Deleted:   []string{"deleted.py"},
+		Merged:    []string{"main.py"},
+		Conflicts: []string{"conflict.py"},
+	}, result)
+
+	assertTestFile(t, testLocalPath(t, m, "main.py"), "x\nb\nc\nd\ny\n")
+	assertTestFile(t, testLocalPath(t, m, "local.py"), "local\n")
+	assertTestFile(t, testLocalPath(t, m, "remote.py"), "remote\n")
+	assertTestFile(t, testLocalPath(t, m, "local_new.py"), "local\n")
+	assertTestFile(t, testLocalPath(t, m, "conflict.py"), "<<<<<<< local\nlocal\n=======\nremote\n>>>>>>> deployed\n")
+	_, err = os.Stat(testLocalPath(t, m, "deleted.py"))
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+	_, err = os.Stat(testLocalPath(t, m, "_entry.py"))
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+
+	// only local changes are deployed after a pull
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"conflict.py", "local.py", "local_new.py", "main.py"}, sortedKeys(changedPaths(sc)))
+	assert.DeepEqual(t, []string{"local_new.py"}, sc.Additions)
+}
+
+func TestPullMissingBase(t *testing.T) {
+	m := newTestManager(t, "pull_missing_base", map[string]string{
+		"main.py": "base\n",
+	})
+	_, err := m.Pull(newTestZip(t, map[string]string{"main.py": "base\n"}), true)
+	assert.NilError(t, err)
+
+	s, err := m.getStoredState()
+	assert.NilError(t, err)
+	err = os.Remove(m.objectPath(s.Files["main.py"].Checksum))
+	assert.NilError(t, err)
+
+	// the local file is kept if the base of the merge is not in the history
+	writeTestFile(t, testLocalPath(t, m, "main.py"), "local\n")
+	result, err := m.Pull(newTestZip(t, map[string]string{"main.py": "remote\n"}), true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"main.py"}, result.Conflicts)
+	assert.Assert(t, result.Reasons["main.py"] != "")
+	assertTestFile(t, testLocalPath(t, m, "main.py"), "local\n")
+}
+
+func assertTestFile(t *testing.T, path, content string) {
+	contents, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, content, string(contents), path)
+}
+
+func changedPaths(sc *StateChanges) map[string]string {
+	paths := make(map[string]string, len(sc.Files))
+	for path, f := range sc.Files {
+		paths[path] = f.Checksum
+	}
+	return paths
+}
+
+func TestLink(t *testing.T) {
+	m := newTestManager(t, "link", map[string]string{
+		"main.py":  "print('hello')\n",
+		"utils.py": "local\n",
+		"local.py": "local\n",
+	})
+
+	deployed := newTestZip(t, map[string]string{
+		"main.py":   "print('hello')\n",
+		"utils.py":  "remote\n",
+		"remote.py": "remote\n",
+		"_entry.py": "lib\n",
+	})
+	differ, err := m.Link(deployed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"local.py", "remote.py", "utils.py"}, differ)
+
+	// files are not changed
+	assertTestFile(t, testLocalPath(t, m, "utils.py"), "local\n")
+	_, err = os.Stat(testLocalPath(t, m, "remote.py"))
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+
+	// only the differences are deployed on the next deploy
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"local.py", "utils.py"}, sortedKeys(changedPaths(sc)))
+	assert.DeepEqual(t, []string{"local.py"}, sc.Additions)
+	assert.DeepEqual(t, []string{"remote.py"}, sc.Deletions)
+
+	drift, err := m.Drift(deployed)
+	assert.NilError(t, err)
+	assert.Assert(t, drift == nil)
+}
@@ -0,0 +1,128 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+const (
+	// dir under the deta dir storing the env profiles
+	envsDir = "envs"
+	// env file of an env profile in the dir of the profile
+	profileEnvFile = ".env"
+)
+
+var (
+	// names of env profiles, also used as dir names
+	profileNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+)
+
+// SetProfile sets the env profile of the root dir, the default profile if profile is empty
+// each profile links the root dir to a different micro with its own program info, state and history
+func (m *Manager) SetProfile(profile string) error {
+	if profile != "" && !profileNameRegexp.MatchString(profile) {
+		return fmt.Errorf("invalid env profile '%s', only letters, digits, '-' and '_' are allowed", profile)
+	}
+	m.profile = profile
+	m.progInfoPath = filepath.Join(m.profilePath(), progInfoFile)
+	m.statePath = filepath.Join(m.profilePath(), stateFile)
+	return nil
+}
+
+// Profile gets the env profile of the root dir, empty for the default profile
+func (m *Manager) Profile() string {
+	return m.profile
+}
+
+// profilePath gets the dir storing program info, state and history of the profile
+func (m *Manager) profilePath() string {
+	if m.profile == "" {
+		return m.detaPath
+	}
+	return filepath.Join(m.detaPath, envsDir, m.profile)
+}
+
+// ProfileEnvFile gets the path of the env file of the profile relative to the root dir
+// empty for the default profile or if the profile has no env file
+func (m *Manager) ProfileEnvFile() (string, error) {
+	if m.profile == "" {
+		return "", nil
+	}
+	path := filepath.Join(m.profilePath(), profileEnvFile)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return filepath.Rel(m.rootDir, path)
+}
+
+// Profiles gets the names of the env profiles initialized in the root dir, sorted by name
+// the default profile is not included
+func (m *Manager) Profiles() ([]string, error) {
+	infos, err := ioutil.ReadDir(filepath.Join(m.detaPath, envsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var profiles []string
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(m.detaPath, envsDir, info.Name(), progInfoFile)); err == nil {
+			profiles = append(profiles, info.Name())
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// Unlink removes the program info, state and history of the profile from the root dir
+// the deta dir is removed if nothing else is stored in it
+func (m *Manager) Unlink() error {
+	for _, path := range []string{m.progInfoPath, m.statePath, m.historyPath()} {
+		err := os.RemoveAll(path)
+		if err != nil {
+			return err
+		}
+	}
+	return removeEmptyDirs(m.profileDirs()...)
+}
+
+// profileDirs gets the dirs of the profile from the innermost to the deta dir
+func (m *Manager) profileDirs() []string {
+	if m.profile == "" {
+		return []string{m.detaPath}
+	}
+	return []string{m.profilePath(), filepath.Join(m.detaPath, envsDir), m.detaPath}
+}
+
+// removeEmptyDirs removes dirs in order until a dir is not empty
+func removeEmptyDirs(dirs ...string) error {
+	for _, dir := range dirs {
+		isEmpty, err := isDirEmpty(dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		if !isEmpty {
+			return nil
+		}
+		err = os.Remove(dir)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package runtime
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProfiles(t *testing.T) {
+	m := newTestManager(t, "profiles", map[string]string{
+		"main.py": "print('hello')",
+	})
+	assert.NilError(t, m.StoreProgInfo(&ProgInfo{ID: "prod-id", Runtime: "python3.9"}))
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.NilError(t, m.UpdateState(sc))
+
+	// a new profile is not initialized and has no state
+	assert.NilError(t, m.SetProfile("staging"))
+	assert.Equal(t, "staging", m.Profile())
+	isInitialized, err := m.IsInitialized()
+	assert.NilError(t, err)
+	assert.Assert(t, !isInitialized)
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"main.py"}, sortedKeys(changedPaths(sc)))
+
+	assert.NilError(t, m.StoreProgInfo(&ProgInfo{ID: "staging-id", Runtime: "python3.9"}))
+	progInfo, err := m.GetProgInfo()
+	assert.NilError(t, err)
+	assert.Equal(t, "staging-id", progInfo.ID)
+
+	// the default profile is unchanged
+	assert.NilError(t, m.SetProfile(""))
+	progInfo, err = m.GetProgInfo()
+	assert.NilError(t, err)
+	assert.Equal(t, "prod-id", progInfo.ID)
+	sc, err = m.GetChanges()
+	assert.NilError(t, err)
+	assert.Assert(t, sc == nil)
+
+	profiles, err := m.Profiles()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"staging"}, profiles)
+
+	assert.ErrorContains(t, m.SetProfile("../prod"), "invalid env profile")
+}
+
+func TestUnlink(t *testing.T) {
+	m := newTestManager(t, "unlink", map[string]string{
+		"main.py": "print('hello')",
+	})
+	assert.NilError(t, m.StoreProgInfo(&ProgInfo{ID: "prod-id", Runtime: "python3.9"}))
+	assert.NilError(t, m.SetProfile("staging"))
+	assert.NilError(t, m.StoreProgInfo(&ProgInfo{ID: "staging-id", Runtime: "python3.9"}))
+	sc, err := m.GetChanges()
+	assert.NilError(t, err)
+	assert.NilError(t, m.UpdateState(sc))
+	_, err = m.StoreSnapshot()
+	assert.NilError(t, err)
+
+	// the deta dir is kept for the default profile
+	assert.NilError(t, m.Unlink())
+	_, err = os.Stat(m.profilePath())
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+	_, err = os.Stat(m.detaPath)
+	assert.NilError(t, err)
+
+	assert.NilError(t, m.SetProfile(""))
+	assert.NilError(t, m.Unlink())
+	_, err = os.Stat(m.detaPath)
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+	assertTestFile(t, testLocalPath(t, m, "main.py"), "print('hello')")
+}
+
+func TestProfileEnvFile(t *testing.T) {
+	m := newTestManager(t, "profile_env", map[string]string{
+		"main.py": "print('hello')",
+		".env":    "KEY=default",
+	})
+
+	// the default profile has no env file of its own
+	envFile, err := m.ProfileEnvFile()
+	assert.NilError(t, err)
+	assert.Equal(t, "", envFile)
+
+	assert.NilError(t, m.SetProfile("staging"))
+	envFile, err = m.ProfileEnvFile()
+	assert.NilError(t, err)
+	assert.Equal(t, "", envFile)
+
+	writeTestFile(t, filepath.Join(m.profilePath(), profileEnvFile), "KEY=staging")
+	envFile, err = m.ProfileEnvFile()
+	assert.NilError(t, err)
+	assert.Equal(t, filepath.Join(detaDir, envsDir, "staging", profileEnvFile), envFile)
+	envs, err := m.readEnvs(envFile)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]string{"KEY": "staging"}, envs)
+}
+
+func TestCleanProfile(t *testing.T) {
+	m := newTestManager(t, "clean_profile", map[string]string{
+		"main.py": "print('hello')",
+	})
+	assert.NilError(t, os.MkdirAll(m.detaPath, dirPermMode))
+	assert.NilError(t, m.SetProfile("staging"))
+	assert.NilError(t, os.MkdirAll(m.profilePath(), dirPermMode))
+
+	// the shared deta dir is kept when cleaning a profile
+	assert.NilError(t, m.Clean())
+	_, err := os.Stat(filepath.Join(m.detaPath, envsDir))
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+	_, err = os.Stat(m.detaPath)
+	assert.NilError(t, err)
+
+	assert.NilError(t, m.SetProfile(""))
+	assert.NilError(t, m.Clean())
+	_, err = os.Stat(m.detaPath)
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+}
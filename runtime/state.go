@@ -1,9 +1,47 @@
 package runtime
 
-import "encoding/json"
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
 
-// map filepath to checksum
-type stateMap map[string]string
+const (
+	// version of the state format
+	stateVersion = 1
+)
+
+// fileState stored state of a file
+type fileState struct {
+	Checksum string `json:"checksum"`
+	Size     int64  `json:"size"`
+	ModTime  int64  `json:"mtime"` // modification time in nanoseconds since unix epoch
+}
+
+// UnmarshalJSON unmarshals a file state, supports the old format of only the checksum
+func (f *fileState) UnmarshalJSON(data []byte) error {
+	var checksum string
+	if err := json.Unmarshal(data, &checksum); err == nil {
+		f.Checksum = checksum
+		return nil
+	}
+	// alias to not recurse into UnmarshalJSON
+	type state fileState
+	return json.Unmarshal(data, (*state)(f))
+}
+
+// isUnchanged checks if the file has the same size and modification time as stored
+// files stored without size and modification time are never unchanged
+func (f *fileState) isUnchanged(info os.FileInfo) bool {
+	return f.ModTime != 0 && f.ModTime == info.ModTime().UnixNano() && f.Size == info.Size()
+}
+
+// map filepath to file state
+type stateMap map[string]*fileState
 
 // unmarshals data into a stateMap
 func stateMapFromBytes(data []byte) (stateMap, error) {
@@ -15,9 +53,203 @@ func stateMapFromBytes(data []byte) (stateMap, error) {
 	return s, nil
 }
 
+// state stored state of the deployed files of a program
+type state struct {
+	Version    int       `json:"version"`     // version of the state format
+	CLIVersion string    `json:"cli_version"` // version of the cli that stored the state
+	ProgramID  string    `json:"program_id"`
+	DeployedAt time.Time `json:"deployed_at"` // time of the last deployment
+	// fingerprint of the deployed files as last deployed or pulled
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Files       stateMap `json:"files"`
+}
+
+// fingerprint gets the fingerprint of a set of files from the checksums of the files
+func fingerprint(checksums map[string]string) string {
+	paths := make([]string, 0, len(checksums))
+	for path := range checksums {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s\x00%s\n", path, checksums[path])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// checksums gets a map of files to checksums
+func (sm stateMap) checksums() map[string]string {
+	checksums := make(map[string]string, len(sm))
+	for path, f := range sm {
+		checksums[path] = f.Checksum
+	}
+	return checksums
+}
+
+// fingerprint gets the fingerprint of the deployed files, calculated from the files if not stored
+func (s *state) fingerprint() string {
+	if s.Fingerprint != "" {
+		return s.Fingerprint
+	}
+	return fingerprint(s.Files.checksums())
+}
+
+// unmarshals data into a state, migrates states stored in older formats
+func stateFromBytes(data []byte) (*state, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// states before versioning are only a map of files
+	var v int
+	if raw, ok := fields["version"]; !ok || json.Unmarshal(raw, &v) != nil {
+		files, err := stateMapFromBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		return &state{
+			Files: files,
+		}, nil
+	}
+
+	if v > stateVersion {
+		return nil, fmt.Errorf("unsupported state version %d, upgrade the cli with `deta version upgrade`", v)
+	}
+
+	var s state
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Files == nil {
+		s.Files = make(stateMap)
+	}
+	return &s, nil
+}
+
+// FileMeta metadata of a file in the root directory
+type FileMeta struct {
+	Size     int64
+	ModTime  int64 // modification time in nanoseconds since unix epoch
+	Checksum string
+	Binary   bool
+	source   string // path on disk to read the contents from
+}
+
+// fileState the state of the file to store
+func (f *FileMeta) fileState() *fileState {
+	return &fileState{
+		Checksum: f.Checksum,
+		Size:     f.Size,
+		ModTime:  f.ModTime,
+	}
+}
+
+// estimated size of the file contents when sent in a request
+func (f *FileMeta) requestSize() int64 {
+	if f.Binary {
+		return int64(base64.StdEncoding.EncodedLen(int(f.Size)))
+	}
+	return f.Size
+}
+
 // StateChanges changes in state of files of the root directory
+// contents of changed files are only read into Changes and BinaryFiles with Manager.ReadContents
 type StateChanges struct {
-	Changes   map[string]string // map of files to content
-	Deletions []string
-	BinaryFiles map[string]string
+	Changes     map[string]string // map of files to content
+	Deletions   []string
+	BinaryFiles map[string]string    // map of binary files to base64 encoded content
+	Additions   []string             // changed files not present in the stored state
+	Files       map[string]*FileMeta // map of changed files to file metadata
+}
+
+func newStateChanges() *StateChanges {
+	return &StateChanges{
+		Changes:     make(map[string]string),
+		BinaryFiles: make(map[string]string),
+		Files:       make(map[string]*FileMeta),
+	}
+}
+
+// json encoded size of a string
+func jsonSize(s string) int64 {
+	marshalled, err := json.Marshal(s)
+	if err != nil {
+		return int64(len(s))
+	}
+	return int64(len(marshalled))
+}
+
+// Size estimated size of the changes when sent in a request
+func (sc *StateChanges) Size() int64 {
+	var size int64
+	for path, f := range sc.Files {
+		size += jsonSize(path) + f.requestSize()
+	}
+	for _, path := range sc.Deletions {
+		size += jsonSize(path)
+	}
+	return size
+}
+
+// ReleaseContents drops the contents read with Manager.ReadContents
+// the file metadata is kept so the state can still be updated with the changes
+func (sc *StateChanges) ReleaseContents() {
+	sc.Changes = make(map[string]string)
+	sc.BinaryFiles = make(map[string]string)
+}
+
+// Split splits changes into batches of estimated size at most maxSize
+// a file larger than maxSize is put in a batch of its own
+// deletions are put in the last batch so files are only deleted after all other changes are sent
+func (sc *StateChanges) Split(maxSize int64) []*StateChanges {
+	additions := make(map[string]struct{}, len(sc.Additions))
+	for _, a := range sc.Additions {
+		additions[a] = struct{}{}
+	}
+
+	paths := make([]string, 0, len(sc.Files))
+	for path := range sc.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var batches []*StateChanges
+	batch := newStateChanges()
+	var batchSize int64
+	for _, path := range paths {
+		f := sc.Files[path]
+		size := jsonSize(path) + f.requestSize()
+		if batchSize > 0 && batchSize+size > maxSize {
+			batches = append(batches, batch)
+			batch = newStateChanges()
+			batchSize = 0
+		}
+
+		batch.Files[path] = f
+		if _, ok := additions[path]; ok {
+			batch.Additions = append(batch.Additions, path)
+		}
+		batchSize += size
+	}
+
+	for _, path := range sc.Deletions {
+		size := jsonSize(path)
+		if batchSize > 0 && batchSize+size > maxSize {
+			batches = append(batches, batch)
+			batch = newStateChanges()
+			batchSize = 0
+		}
+		batch.Deletions = append(batch.Deletions, path)
+		batchSize += size
+	}
+
+	if batchSize > 0 || len(batches) == 0 {
+		batches = append(batches, batch)
+	}
+	return batches
 }
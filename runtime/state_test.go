@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestStateChangesSplit(t *testing.T) {
+	sc := &StateChanges{
+		Files: map[string]*FileMeta{
+			"a.py":  {Size: 40, Checksum: "checksum-a"},
+			"b.py":  {Size: 40, Checksum: "checksum-b"},
+			"c.py":  {Size: 200, Checksum: "checksum-c"},
+			"d.png": {Size: 30, Checksum: "checksum-d", Binary: true},
+		},
+		Additions: []string{"c.py"},
+		Deletions: []string{"e.py", "f.py"},
+	}
+
+	batches := sc.Split(100)
+	assert.Equal(t, 3, len(batches))
+
+	// files are batched in sorted order
+	assert.DeepEqual(t, batchPaths(batches[0]), []string{"a.py", "b.py"})
+	assert.Equal(t, sc.Files["a.py"], batches[0].Files["a.py"])
+
+	// files larger than the max size get a batch of their own
+	assert.DeepEqual(t, batchPaths(batches[1]), []string{"c.py"})
+	assert.DeepEqual(t, batches[1].Additions, []string{"c.py"})
+
+	// binary files are estimated with their base64 encoded size
+	// deletions are sent last
+	assert.DeepEqual(t, batchPaths(batches[2]), []string{"d.png"})
+	assert.DeepEqual(t, batches[2].Deletions, []string{"e.py", "f.py"})
+	assert.Equal(t, int64(7+40+6+6), batches[2].Size())
+
+	// no batch is larger than the max size unless it has a single file
+	for _, b := range batches {
+		assert.Assert(t, b.Size() <= 100 || len(b.Files) == 1)
+	}
+
+	// everything fits in a single batch
+	batches = sc.Split(sc.Size())
+	assert.Equal(t, 1, len(batches))
+	assert.Equal(t, sc.Size(), batches[0].Size())
+}
+
+func TestStateChangesReleaseContents(t *testing.T) {
+	sc := newStateChanges()
+	sc.Files["a.py"] = &FileMeta{Size: 5, Checksum: "checksum-a"}
+	sc.Files["b.png"] = &FileMeta{Size: 3, Checksum: "checksum-b", Binary: true}
+	sc.Changes["a.py"] = "hello"
+	sc.BinaryFiles["b.png"] = "AAAA"
+	size := sc.Size()
+
+	sc.ReleaseContents()
+	assert.Equal(t, 0, len(sc.Changes))
+	assert.Equal(t, 0, len(sc.BinaryFiles))
+
+	// metadata is kept to update the state and estimate the size
+	assert.Equal(t, 2, len(sc.Files))
+	assert.Equal(t, size, sc.Size())
+}
+
+// sorted paths of the files in a batch
+func batchPaths(b *StateChanges) []string {
+	var paths []string
+	for path := range b.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestHashFile(t *testing.T) {
+	m := &Manager{}
+	testCases := []struct {
+		path   string
+		binary bool
+	}{
+		{filepath.Join("testdata", "non_binary", "main.py"), false},
+		{filepath.Join("testdata", "non_binary", "file.html"), false},
+		{filepath.Join("testdata", "binary", "test.png"), true},
+		{filepath.Join("testdata", "binary", "test.pdf"), true},
+	}
+	for _, tc := range testCases {
+		f, err := m.hashFile(tc.path)
+		assert.NilError(t, err)
+
+		contents, err := m.readFile(tc.path)
+		assert.NilError(t, err)
+		assert.Equal(t, int64(len(contents)), f.Size, tc.path)
+		assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(contents)), f.Checksum, tc.path)
+		assert.Equal(t, tc.binary, f.Binary, tc.path)
+	}
+}
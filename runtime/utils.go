@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -112,6 +113,37 @@ func unzip(zipFile []byte, dest string, skipFileNames []string) error {
 	return nil
 }
 
+// readZip reads the files of a zip file into a map of slash separated paths to contents skipping skipFileNames
+func readZip(zipFile []byte, skipFileNames []string) (map[string][]byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(zipFile), int64(len(zipFile)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.TrimPrefix(filepath.ToSlash(f.Name), "/")
+		if contains(skipFileNames, name) {
+			continue
+		}
+
+		srcFile, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := ioutil.ReadAll(srcFile)
+		srcFile.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[name] = contents
+	}
+	return files, nil
+}
+
 // check if data is binary content type
 func isBinary(data []byte) bool {
 	nonBinaryPrefixes := []string{